@@ -0,0 +1,142 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"regexp"
+	"strings"
+)
+
+// Attachment is a file attached to a secret, e.g. a certificate.
+type Attachment struct {
+	// ID identifies the attachment within its secret, e.g. "att-42".
+	ID       string
+	Filename string
+	Content  []byte
+}
+
+// AttachmentBackend is implemented by backends able to read attachments.
+type AttachmentBackend interface {
+	Attachments(ctx context.Context, id string) ([]Attachment, error)
+}
+
+// AttachmentWriter is implemented by backends able to upload and remove
+// attachments, lpass can only read them.
+type AttachmentWriter interface {
+	AddAttachment(ctx context.Context, id string, a Attachment) (Attachment, error)
+	DeleteAttachment(ctx context.Context, id, attachmentID string) error
+}
+
+// Attachments returns the attachments of the secret with the given ID,
+// including their content.
+func (c *Client) Attachments(id string) ([]Attachment, error) {
+	return c.AttachmentsContext(context.Background(), id)
+}
+
+// AttachmentsContext is like Attachments, but gives up when ctx is done.
+func (c *Client) AttachmentsContext(ctx context.Context, id string) ([]Attachment, error) {
+	b, ok := c.backend().(AttachmentBackend)
+	if !ok {
+		return nil, &Error{Err: ErrUnsupported, Message: "attachments are only supported by the lpass backend"}
+	}
+	err := c.login(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return b.Attachments(ctx, id)
+}
+
+// AddAttachment uploads a file to the secret with the given ID.
+func (c *Client) AddAttachment(id string, a Attachment) (Attachment, error) {
+	return c.AddAttachmentContext(context.Background(), id, a)
+}
+
+// AddAttachmentContext is like AddAttachment, but gives up when ctx is done.
+func (c *Client) AddAttachmentContext(ctx context.Context, id string, a Attachment) (Attachment, error) {
+	b, err := c.attachmentWriter(ctx)
+	if err != nil {
+		return a, err
+	}
+	added := a
+	err = c.writeNow(ctx, "add attachment to "+id, func(ctx context.Context) error {
+		added, err = b.AddAttachment(ctx, id, a)
+		return err
+	})
+	return added, err
+}
+
+// DeleteAttachment removes an attachment from the secret with the given ID.
+func (c *Client) DeleteAttachment(id, attachmentID string) error {
+	return c.DeleteAttachmentContext(context.Background(), id, attachmentID)
+}
+
+// DeleteAttachmentContext is like DeleteAttachment, but gives up when ctx is done.
+func (c *Client) DeleteAttachmentContext(ctx context.Context, id, attachmentID string) error {
+	b, err := c.attachmentWriter(ctx)
+	if err != nil {
+		return err
+	}
+	return c.writeNow(ctx, "delete attachment "+attachmentID, func(ctx context.Context) error {
+		return b.DeleteAttachment(ctx, id, attachmentID)
+	})
+}
+
+// CanWriteAttachments reports whether the backend can upload and remove
+// attachments.
+func (c *Client) CanWriteAttachments() bool {
+	_, ok := c.backend().(AttachmentWriter)
+	return ok
+}
+
+// attachmentWriter logs in and returns the backend if it can change attachments.
+func (c *Client) attachmentWriter(ctx context.Context) (AttachmentWriter, error) {
+	b, ok := c.backend().(AttachmentWriter)
+	if !ok {
+		return nil, &Error{Err: ErrUnsupported, Message: "lpass can't upload or remove attachments, manage them in the Lastpass web interface"}
+	}
+	return b, c.login(ctx)
+}
+
+// attachmentLine matches the attachments listed by lpass show, e.g.
+// "att-42: server.pem".
+var attachmentLine = regexp.MustCompile(`^(att-[0-9-]+): (.*)$`)
+
+// Attachments lists the attachments with lpass show, and fetches each of
+// them with lpass show --attach.
+func (b *CLIBackend) Attachments(ctx context.Context, id string) ([]Attachment, error) {
+	out, err := b.show(ctx, "--sync=auto", "--color=never", id)
+	if err != nil {
+		return nil, err
+	}
+	var attachments []Attachment
+	for _, line := range strings.Split(out, "\n") {
+		if strings.HasPrefix(line, "Notes:") {
+			// the rest is the note, which could contain anything
+			break
+		}
+		m := attachmentLine.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		// --quiet writes binary files to stdout instead of asking
+		content, err := b.show(ctx, "--sync=auto", "--quiet", "--attach="+m[1], id)
+		if err != nil {
+			return nil, err
+		}
+		attachments = append(attachments, Attachment{ID: m[1], Filename: m[2], Content: []byte(content)})
+	}
+	return attachments, nil
+}
+
+// show runs lpass show and returns its output.
+func (b *CLIBackend) show(ctx context.Context, args ...string) (string, error) {
+	var outbuf, errbuf bytes.Buffer
+	cmd := b.command(ctx, append([]string{"show"}, args...)...)
+	cmd.Stdout = &outbuf
+	cmd.Stderr = &errbuf
+	err := cmd.Run()
+	if err != nil {
+		return "", commandError(ctx, errbuf.String())
+	}
+	return outbuf.String(), nil
+}
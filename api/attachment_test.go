@@ -0,0 +1,76 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestCLIBackendAttachments(t *testing.T) {
+	_, b := newFakeLpass(t, fakeState{
+		LoggedIn: true,
+		Secrets: []fakeSecret{{
+			ID:       "1",
+			Fullname: "Infra/tls",
+			// a line of the note looking like an attachment is ignored
+			Note: "see below\natt-9: not an attachment",
+			Attachments: map[string]fakeAttachment{
+				"att-1-1": {Filename: "server.pem", Content: []byte("-----BEGIN CERTIFICATE-----\n")},
+				"att-1-2": {Filename: "keystore.jks", Content: []byte("\x00\xfe\xed")},
+			},
+		}, {ID: "2", Fullname: "plain"}},
+	})
+	attachments, err := b.Attachments(context.Background(), "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Attachment{
+		{ID: "att-1-1", Filename: "server.pem", Content: []byte("-----BEGIN CERTIFICATE-----\n")},
+		{ID: "att-1-2", Filename: "keystore.jks", Content: []byte("\x00\xfe\xed")},
+	}
+	if !reflect.DeepEqual(attachments, want) {
+		t.Errorf("got %+v, want %+v", attachments, want)
+	}
+	attachments, err = b.Attachments(context.Background(), "2")
+	if err != nil || len(attachments) != 0 {
+		t.Errorf("expected no attachments, got %v, %v", attachments, err)
+	}
+	_, err = b.Attachments(context.Background(), "3")
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestClientAddAttachmentUnsupported(t *testing.T) {
+	_, b := newFakeLpass(t, fakeState{LoggedIn: true, Secrets: []fakeSecret{{ID: "1", Fullname: "tls"}}})
+	client := &Client{Backend: b}
+	_, err := client.AddAttachment("1", Attachment{Filename: "server.pem"})
+	if !errors.Is(err, ErrUnsupported) {
+		t.Errorf("expected ErrUnsupported, got %v", err)
+	}
+}
+
+func TestMemoryBackendAttachments(t *testing.T) {
+	client := &Client{Backend: &MemoryBackend{}}
+	s, err := client.Create(Secret{Name: "tls"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, err := client.AddAttachment(s.ID, Attachment{Filename: "server.pem", Content: []byte("key")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	attachments, err := client.Attachments(s.ID)
+	if err != nil || len(attachments) != 1 || attachments[0].ID != a.ID {
+		t.Fatalf("unexpected attachments %v, %v", attachments, err)
+	}
+	err = client.DeleteAttachment(s.ID, a.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = client.DeleteAttachment(s.ID, a.ID)
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
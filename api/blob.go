@@ -0,0 +1,128 @@
+package api
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+)
+
+// Positions of the fields we use inside an ACCT chunk of the vault blob.
+const (
+	acctID              = 0
+	acctName            = 1
+	acctGroup           = 2
+	acctURL             = 3
+	acctNote            = 4
+	acctUsername        = 7
+	acctPassword        = 8
+	acctLastTouch       = 12
+	acctLastModifiedGmt = 31
+	acctLastPwChangeGmt = 33
+)
+
+// readChunk splits the next length prefixed item off data.
+func readChunk(data []byte) (item, rest []byte, err error) {
+	if len(data) < 4 {
+		return nil, nil, errors.New("truncated vault blob")
+	}
+	size := binary.BigEndian.Uint32(data)
+	data = data[4:]
+	if uint32(len(data)) < size {
+		return nil, nil, errors.New("truncated vault blob")
+	}
+	return data[:size], data[size:], nil
+}
+
+// parseBlob decrypts the accounts in a vault blob downloaded from getaccts.php.
+// Accounts inside shared folders are encrypted with the share key, they are
+// skipped and returned in shared as a map from account ID to share ID.
+func parseBlob(blob, key []byte) (secrets []Secret, shared map[string]string, err error) {
+	shared = make(map[string]string)
+	share := ""
+	for len(blob) > 0 {
+		if len(blob) < 4 {
+			return nil, nil, errors.New("truncated vault blob")
+		}
+		tag := string(blob[:4])
+		chunk, rest, err := readChunk(blob[4:])
+		if err != nil {
+			return nil, nil, err
+		}
+		blob = rest
+		switch tag {
+		case "SHAR":
+			// every account after a share belongs to it, up to the next one
+			id, _, err := readChunk(chunk)
+			if err != nil {
+				return nil, nil, err
+			}
+			share = string(id)
+		case "ACCT":
+			if share != "" {
+				id, _, err := readChunk(chunk)
+				if err != nil {
+					return nil, nil, err
+				}
+				shared[string(id)] = share
+				continue
+			}
+			s, err := parseAccount(chunk, key)
+			if err != nil {
+				return nil, nil, err
+			}
+			secrets = append(secrets, s)
+		}
+	}
+	return secrets, shared, nil
+}
+
+func parseAccount(chunk, key []byte) (Secret, error) {
+	var s Secret
+	var items [][]byte
+	for len(chunk) > 0 {
+		item, rest, err := readChunk(chunk)
+		if err != nil {
+			return s, err
+		}
+		items = append(items, item)
+		chunk = rest
+	}
+	field := func(i int) []byte {
+		if i < len(items) {
+			return items[i]
+		}
+		return nil
+	}
+	var err error
+	decrypt := func(i int) string {
+		if err != nil {
+			return ""
+		}
+		var v string
+		v, err = decryptItem(field(i), key)
+		return v
+	}
+	s.ID = string(field(acctID))
+	s.Name = decrypt(acctName)
+	s.Group = decrypt(acctGroup)
+	s.Note = decrypt(acctNote)
+	s.Username = decrypt(acctUsername)
+	s.Password = decrypt(acctPassword)
+	if err != nil {
+		return s, err
+	}
+	url, err := hex.DecodeString(string(field(acctURL)))
+	if err != nil {
+		return s, err
+	}
+	s.URL = string(url)
+	s.LastTouch = string(field(acctLastTouch))
+	s.LastModifiedGmt = string(field(acctLastModifiedGmt))
+	s.LastPasswordChangeGmt = string(field(acctLastPwChangeGmt))
+	s.Fullname = s.Name
+	if s.Group != "" {
+		s.Fullname = s.Group + "/" + s.Name
+	}
+	s.Name = s.Fullname // same as the lpass backend, name includes the path
+	return s, nil
+}
@@ -0,0 +1,186 @@
+package api
+
+import (
+	"context"
+	"errors"
+)
+
+// VaultBackend is implemented by backends able to load every secret,
+// passwords and notes included, at once. The client caches the vault to
+// serve Read and List without starting a lpass process for each secret.
+type VaultBackend interface {
+	Vault(ctx context.Context) ([]Secret, error)
+}
+
+// vaultReader is implemented by vault backends able to read a single secret
+// the way Vault returns it, cheaper than loading the vault again.
+type vaultReader interface {
+	vaultRead(ctx context.Context, id string) ([]Secret, error)
+}
+
+// vault returns the cached vault, loading it when needed. Secrets changed
+// since it was loaded are read again on their own when the backend can,
+// otherwise the whole vault is. ok is false for backends without
+// VaultBackend.
+func (c *Client) vault(ctx context.Context) (secrets []Secret, ok bool, err error) {
+	b, ok := c.backend().(VaultBackend)
+	if !ok {
+		return nil, false, nil
+	}
+	// concurrent callers wait for the first one to load the vault
+	c.cacheMu.Lock()
+	defer c.cacheMu.Unlock()
+	r, canRead := b.(vaultReader)
+	if c.cache == nil || len(c.stale) > 0 && !canRead {
+		all, err := b.Vault(ctx)
+		if err != nil {
+			return nil, true, c.checkSession(err)
+		}
+		c.cache = append([]Secret{}, all...)
+		c.stale = nil
+	}
+	for id := range c.stale {
+		found, err := r.vaultRead(ctx, id)
+		if err != nil {
+			return nil, true, c.checkSession(err)
+		}
+		c.cache = append(c.cache, found...)
+		delete(c.stale, id)
+	}
+	return c.cache, true, nil
+}
+
+// evict drops the secret with the given ID from the cached vault after a
+// change to it, the next Read reads it again.
+func (c *Client) evict(id string) {
+	c.cacheMu.Lock()
+	defer c.cacheMu.Unlock()
+	if c.cache == nil {
+		return
+	}
+	kept := c.cache[:0]
+	for _, s := range c.cache {
+		if s.ID != id {
+			kept = append(kept, s)
+		}
+	}
+	c.cache = kept
+	if c.stale == nil {
+		c.stale = make(map[string]bool)
+	}
+	c.stale[id] = true
+}
+
+// evictCreated is evict for a create, a failed one may still have added a
+// secret the cache doesn't know the ID of.
+func (c *Client) evictCreated(id string, err error) {
+	if err != nil || id == "" {
+		c.invalidate()
+		return
+	}
+	c.evict(id)
+}
+
+// invalidate drops the cached vault, the next Read loads it again.
+func (c *Client) invalidate() {
+	c.cacheMu.Lock()
+	defer c.cacheMu.Unlock()
+	c.cache = nil
+	c.stale = nil
+}
+
+// cachedRead reads a secret from the cached vault, or the backend when it
+// can't be cached. Writes through the client
+// evict what they change, so a secret missing from a loaded vault doesn't exist.
+// Backends able to explain why a secret is missing, e.g. the native backend
+// for secrets in shared folders, are asked for the error.
+func (c *Client) cachedRead(ctx context.Context, id string) ([]Secret, error) {
+	all, ok, err := c.vault(ctx)
+	if err != nil {
+		return nil, err
+	} else if !ok {
+		secrets, err := c.backend().Read(ctx, id)
+		return secrets, c.checkSession(err)
+	}
+	var secrets []Secret
+	for _, s := range all {
+		if s.ID == id {
+			secrets = append(secrets, s)
+		}
+	}
+	if len(secrets) > 0 {
+		return secrets, nil
+	}
+	if b, ok := c.backend().(interface{ missing(id string) error }); ok {
+		return nil, b.missing(id)
+	}
+	return nil, &Error{Err: ErrNotFound, Message: id}
+}
+
+// cachedList lists the cached vault, or the backend when it can't be cached.
+func (c *Client) cachedList(ctx context.Context) ([]Secret, error) {
+	all, ok, err := c.vault(ctx)
+	if err != nil || ok {
+		return append([]Secret(nil), all...), err
+	}
+	secrets, err := c.backend().List(ctx)
+	return secrets, c.checkSession(err)
+}
+
+// Vault loads every secret with a single lpass show, matching any name. With
+// PasswordChanges it also downloads the vault from the Lastpass API for when
+// the passwords last changed.
+func (b *CLIBackend) Vault(ctx context.Context) ([]Secret, error) {
+	all, err := b.Read(ctx, ".")
+	if errors.Is(err, ErrNotFound) {
+		// an empty vault
+		return []Secret{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return b.vaultSecrets(ctx, all, "")
+}
+
+// vaultRead reads the secret with the given ID for the cached vault with a
+// lpass show of its own. A missing secret was deleted.
+func (b *CLIBackend) vaultRead(ctx context.Context, id string) ([]Secret, error) {
+	found, err := b.Read(ctx, id)
+	if errors.Is(err, ErrNotFound) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return b.vaultSecrets(ctx, found, id)
+}
+
+// vaultSecrets leaves out secrets not synced yet and, when id is set, those
+// lpass show matched with another ID. It adds when the passwords last
+// changed with PasswordChanges.
+func (b *CLIBackend) vaultSecrets(ctx context.Context, found []Secret, id string) ([]Secret, error) {
+	var changed map[string]string
+	if b.PasswordChanges != nil {
+		native, err := b.PasswordChanges.vault(ctx)
+		if err != nil {
+			return nil, err
+		}
+		changed = make(map[string]string, len(native))
+		for _, s := range native {
+			changed[s.ID] = s.LastPasswordChangeGmt
+		}
+	}
+	secrets := []Secret{}
+	for _, s := range found {
+		if s.ID == "0" || id != "" && s.ID != id {
+			// not synced yet, or another secret
+			continue
+		}
+		s.LastPasswordChangeGmt = changed[s.ID]
+		secrets = append(secrets, s)
+	}
+	return secrets, nil
+}
+
+// Vault downloads and decrypts the account list once.
+func (b *NativeBackend) Vault(ctx context.Context) ([]Secret, error) {
+	return b.vault(ctx)
+}
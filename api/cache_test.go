@@ -0,0 +1,123 @@
+package api
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func countShows(f *fakeLpass) int {
+	return countCalls(f, "show")
+}
+
+// countCalls counts the lpass invocations starting with prefix.
+func countCalls(f *fakeLpass, prefix string) int {
+	var calls int
+	for _, call := range f.state().Calls {
+		if strings.HasPrefix(call, prefix) {
+			calls++
+		}
+	}
+	return calls
+}
+
+func TestClientReadCache(t *testing.T) {
+	f, b := newFakeLpass(t, fakeState{LoggedIn: true, Secrets: []fakeSecret{
+		{ID: "1", Fullname: "Infra/db", Password: "one"},
+		{ID: "2", Fullname: "Infra/web", Password: "two"},
+		{ID: "3", Fullname: "dns", Password: "three"},
+	}, LastID: 3})
+	client := Client{Backend: b}
+	for _, id := range []string{"1", "2", "3", "2"} {
+		secrets, err := client.Read(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(secrets) != 1 || secrets[0].ID != id {
+			t.Fatalf("Read(%s): unexpected secrets %+v", id, secrets)
+		}
+	}
+	if shows := countShows(f); shows != 1 {
+		t.Errorf("expected the vault to be loaded with 1 show call, got %d", shows)
+	}
+	err := client.Update(Secret{ID: "2", Name: "Infra/web", Password: "changed"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	created, err := client.Create(Secret{Name: "mail", Password: "four"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	shows := countShows(f)
+	for id, want := range map[string]string{"1": "one", "2": "changed", created.ID: "four"} {
+		secrets, err := client.Read(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if secrets[0].Password != want {
+			t.Errorf("Read(%s): got password %q, want %q", id, secrets[0].Password, want)
+		}
+	}
+	// only the changed secrets are read again, each with its own show
+	if got := countShows(f) - shows; got != 2 {
+		t.Errorf("expected 2 show calls for the changed secrets, got %d", got)
+	}
+	if got := countCalls(f, "show --sync=auto -G . "); got != 1 {
+		t.Errorf("expected the vault to be loaded once, got %d", got)
+	}
+	err = client.Delete("3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Read("3"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected a deleted secret to be evicted, got %v", err)
+	}
+	if status := countCalls(f, "status"); status != 1 {
+		t.Errorf("expected the login to be checked once, got %d status calls", status)
+	}
+}
+
+func TestClientSessionExpired(t *testing.T) {
+	f, b := newFakeLpass(t, fakeState{LoggedIn: true, Username: "gopher@example.com", Password: "hunter2", Secrets: []fakeSecret{{ID: "1", Fullname: "db"}}})
+	client := Client{Username: "gopher@example.com", Password: "hunter2", Backend: b}
+	if _, err := client.List(ListFilter{}); err != nil {
+		t.Fatal(err)
+	}
+	// the session ends behind our back, the next change fails and the one
+	// after it logs in again
+	state := f.state()
+	state.LoggedIn = false
+	f.save(state)
+	if err := client.Update(Secret{ID: "1", Name: "db", Password: "pw"}); !errors.Is(err, ErrNotLoggedIn) {
+		t.Fatalf("expected a not logged in error, got %v", err)
+	}
+	if err := client.Update(Secret{ID: "1", Name: "db", Password: "pw"}); err != nil {
+		t.Fatal(err)
+	}
+	if status := countCalls(f, "status"); status != 2 {
+		t.Errorf("expected the login to be checked again after the session ended, got %d status calls", status)
+	}
+}
+
+func TestClientReadCacheMiss(t *testing.T) {
+	f, b := newFakeLpass(t, fakeState{LoggedIn: true, Secrets: []fakeSecret{
+		{ID: "1", Fullname: "db"},
+	}})
+	client := Client{Backend: b}
+	if _, err := client.Read("1"); err != nil {
+		t.Fatal(err)
+	}
+	// a secret missing from the loaded vault is not found, without
+	// falling back to a lpass show matching names
+	state := f.state()
+	state.Secrets = append(state.Secrets, fakeSecret{ID: "2", Fullname: "web"})
+	f.save(state)
+	for _, id := range []string{"2", "3", "db"} {
+		if _, err := client.Read(id); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Read(%s): expected not found error, got %v", id, err)
+		}
+	}
+	if shows := countShows(f); shows != 1 {
+		t.Errorf("expected only the vault to be loaded, got %d show calls", shows)
+	}
+}
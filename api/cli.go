@@ -0,0 +1,157 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// CLIBackend talks to Lastpass through the lpass command line client.
+type CLIBackend struct {
+	// Path to the lpass binary, defaults to lpass from $PATH.
+	Path string
+	// Home sets LPASS_HOME, where lpass keeps its session and blob.
+	Home string
+	// Env is extra environment passed to every lpass invocation.
+	Env map[string]string
+	// Retry controls how Create waits for lpass to sync new secrets.
+	Retry RetryPolicy
+	// PasswordChanges, when set, reads when passwords last changed from
+	// the Lastpass API, lpass doesn't print it. It logs in with the same
+	// credentials as lpass.
+	PasswordChanges *NativeBackend
+}
+
+// command prepares lpass, the process is killed if ctx is done.
+func (b *CLIBackend) command(ctx context.Context, args ...string) *exec.Cmd {
+	path := b.Path
+	if path == "" {
+		path = "lpass"
+	}
+	cmd := exec.CommandContext(ctx, path, args...)
+	cmd.Env = b.environ()
+	return cmd
+}
+
+// syncFlag picks how lpass syncs a change. Changes made while others are
+// queued are uploaded in the background and flushed by Sync.
+func (b *CLIBackend) syncFlag(ctx context.Context) string {
+	if syncDeferred(ctx) {
+		return "--sync=auto"
+	}
+	return "--sync=now"
+}
+
+// Sync waits for lpass to upload the changes made with a deferred sync.
+func (b *CLIBackend) Sync(ctx context.Context) error {
+	var errbuf bytes.Buffer
+	cmd := b.command(ctx, "sync", "--color=never")
+	cmd.Stderr = &errbuf
+	err := cmd.Run()
+	if err != nil {
+		return commandError(ctx, errbuf.String())
+	}
+	return nil
+}
+
+// commandError turns a failed lpass run into an error, a cancelled context
+// wins over whatever lpass managed to print before it was killed.
+func commandError(ctx context.Context, stderr string) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return lpassError(stderr)
+}
+
+func (b *CLIBackend) environ() []string {
+	env := os.Environ()
+	keys := make([]string, 0, len(b.Env))
+	for k := range b.Env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		env = append(env, k+"="+b.Env[k])
+	}
+	if b.Home != "" {
+		env = append(env, "LPASS_HOME="+b.Home)
+	}
+	return env
+}
+
+// SessionDir returns a per-account directory suitable as LPASS_HOME, so
+// different accounts never share the same lpass session.
+func SessionDir(username string) (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(strings.ToLower(username)))
+	return filepath.Join(dir, "terraform-provider-lastpass", hex.EncodeToString(sum[:8])), nil
+}
+
+// Login makes sure lpass has an active session, logging in if needed.
+// An existing session must belong to creds.Username.
+func (b *CLIBackend) Login(ctx context.Context, creds Credentials) error {
+	err := b.login(ctx, creds)
+	if err != nil || b.PasswordChanges == nil {
+		return err
+	}
+	return b.PasswordChanges.Login(ctx, creds)
+}
+
+func (b *CLIBackend) login(ctx context.Context, creds Credentials) error {
+	if b.Home != "" {
+		err := os.MkdirAll(b.Home, 0700)
+		if err != nil {
+			return err
+		}
+	}
+	cmd := b.command(ctx, "status")
+	var outbuf bytes.Buffer
+	cmd.Stdout = &outbuf
+	err := cmd.Run()
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if err == nil && creds.Username != "" {
+		// lpass prints "Logged in as user@example.com."
+		current := strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(outbuf.String()), "Logged in as "), ".")
+		if !strings.EqualFold(current, creds.Username) {
+			msg := fmt.Sprintf("lpass is logged in as %s, not %s. Use a separate lpass_home per account", current, creds.Username)
+			return &Error{Err: ErrAuthFailed, Message: msg}
+		}
+	}
+	if err != nil {
+		if creds.Username == "" {
+			return &Error{Err: ErrNotLoggedIn, Message: "please run 'lpass login' manually and try again"}
+		}
+		cmd := b.command(ctx, "login", creds.Username)
+		var inbuf, errbuf bytes.Buffer
+		cmd.Env = append(cmd.Env, "LPASS_DISABLE_PINENTRY=1")
+		// without pinentry lpass reads the password and then the
+		// multifactor code from stdin, out-of-band approval is polled
+		// by lpass itself.
+		inbuf.Write([]byte(creds.Password + "\n"))
+		if creds.OTP != "" {
+			inbuf.Write([]byte(creds.OTP + "\n"))
+		}
+		cmd.Stdin = &inbuf
+		cmd.Stderr = &errbuf
+		err := cmd.Run()
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return loginError("", errbuf.String())
+		}
+	}
+	return nil
+}
@@ -0,0 +1,173 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCLIBackendLogin(t *testing.T) {
+	ctx := context.Background()
+	f, b := newFakeLpass(t, fakeState{Username: "gopher@example.com", Password: "hunter2"})
+	err := b.Login(ctx, Credentials{})
+	if !errors.Is(err, ErrNotLoggedIn) {
+		t.Errorf("expected not logged in error, got %v", err)
+	}
+	err = b.Login(ctx, Credentials{Username: "gopher@example.com", Password: "wrong"})
+	if !errors.Is(err, ErrAuthFailed) || !strings.HasSuffix(err.Error(), "Error: Invalid username or password.") {
+		t.Errorf("expected auth failure with lpass stderr, got %v", err)
+	}
+	err = b.Login(ctx, Credentials{Username: "gopher@example.com", Password: "hunter2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	state := f.state()
+	if !state.LoggedIn {
+		t.Fatal("fake lpass not logged in")
+	}
+	if last := state.Calls[len(state.Calls)-1]; last != "login gopher@example.com [nopinentry]" {
+		t.Errorf("unexpected login call %q", last)
+	}
+	// an active session is reused
+	err = b.Login(ctx, Credentials{Username: "gopher@example.com", Password: "hunter2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls := f.state().Calls; calls[len(calls)-1] != "status" {
+		t.Errorf("expected only a status call, got %q", calls[len(calls)-1])
+	}
+}
+
+func TestCLIBackendLoginMFA(t *testing.T) {
+	ctx := context.Background()
+	state := fakeState{Username: "gopher@example.com", Password: "hunter2", OTP: "123456"}
+	_, b := newFakeLpass(t, state)
+	err := b.Login(ctx, Credentials{Username: "gopher@example.com", Password: "hunter2"})
+	if !errors.Is(err, ErrMFARequired) || !errors.Is(err, ErrAuthFailed) {
+		t.Errorf("expected MFA required error, got %v", err)
+	}
+	err = b.Login(ctx, Credentials{Username: "gopher@example.com", Password: "hunter2", OTP: "654321"})
+	if !errors.Is(err, ErrInvalidOTP) {
+		t.Errorf("expected invalid OTP error, got %v", err)
+	}
+	err = b.Login(ctx, Credentials{Username: "gopher@example.com", Password: "hunter2", OTP: "123456"})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestClientLoginTOTP(t *testing.T) {
+	secret := "JBSWY3DPEHPK3PXP"
+	f, b := newFakeLpass(t, fakeState{Username: "gopher@example.com", Password: "hunter2", TOTPSecret: secret, Secrets: []fakeSecret{{ID: "1"}}})
+	client := Client{Username: "gopher@example.com", Password: "hunter2", TOTPSecret: secret, Backend: b}
+	_, err := client.Read("1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !f.state().LoggedIn {
+		t.Error("not logged in with generated code")
+	}
+	// a new client, this one remembers it is logged in
+	client = Client{Username: "gopher@example.com", Password: "hunter2", TOTPSecret: "invalid!", Backend: &CLIBackend{Path: b.Path}}
+	f.save(fakeState{Username: "gopher@example.com", Password: "hunter2", TOTPSecret: secret})
+	_, err = client.Read("1")
+	if err == nil || !strings.Contains(err.Error(), "invalid TOTP secret") {
+		t.Errorf("expected invalid secret error, got %v", err)
+	}
+}
+
+func TestCLIBackendLoginOtherAccount(t *testing.T) {
+	f, b := newFakeLpass(t, fakeState{LoggedIn: true, Username: "other@example.com", Secrets: []fakeSecret{{ID: "1"}}})
+	client := Client{Username: "gopher@example.com", Password: "hunter2", Backend: b}
+	_, err := client.Create(Secret{Name: "mysecret"})
+	if !errors.Is(err, ErrAuthFailed) || !strings.Contains(err.Error(), "logged in as other@example.com, not gopher@example.com") {
+		t.Errorf("expected account mismatch error, got %v", err)
+	}
+	if len(f.state().Secrets) != 1 {
+		t.Error("secret written to the wrong account")
+	}
+	// case does not matter in e-mail addresses
+	client.Username = "Other@Example.com"
+	_, err = client.Read("1")
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCLIBackendHome(t *testing.T) {
+	ctx := context.Background()
+	f, b := newFakeLpass(t, fakeState{LoggedIn: true})
+	b.Home = filepath.Join(t.TempDir(), "lpass")
+	err := b.Login(ctx, Credentials{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(b.Home); err != nil {
+		t.Errorf("lpass home not created: %s", err)
+	}
+	if env := strings.Join(f.state().LastEnv, "\n"); !strings.Contains(env, "LPASS_HOME="+b.Home) {
+		t.Error("LPASS_HOME not passed to lpass")
+	}
+	a, _ := SessionDir("gopher@example.com")
+	c, _ := SessionDir("Gopher@Example.com")
+	d, _ := SessionDir("other@example.com")
+	if a != c || a == d {
+		t.Errorf("expected one session directory per account, got %s, %s and %s", a, c, d)
+	}
+}
+
+func TestCLIBackendPath(t *testing.T) {
+	ctx := context.Background()
+	if _, ok := (&Client{}).backend().(*CLIBackend); !ok {
+		t.Error("expected lpass to be the default backend")
+	}
+	b := &CLIBackend{}
+	if cmd := b.command(ctx, "status"); cmd.Args[0] != "lpass" {
+		t.Errorf("expected lpass from $PATH, got %q", cmd.Args[0])
+	}
+	b.Path = "/opt/lpass/bin/lpass"
+	if cmd := b.command(ctx, "status"); cmd.Path != "/opt/lpass/bin/lpass" {
+		t.Errorf("expected configured path, got %q", cmd.Path)
+	}
+}
+
+func TestClientLogin(t *testing.T) {
+	_, b := newFakeLpass(t, fakeState{Username: "gopher@example.com", Password: "hunter2"})
+	client := Client{Username: "gopher@example.com", Password: "wrong", Backend: b}
+	_, err := client.Create(Secret{Name: "foo"})
+	if err == nil {
+		t.Error("Create() should fail when login fails")
+	}
+	_, err = client.Read("1")
+	if err == nil {
+		t.Error("Read() should fail when login fails")
+	}
+	err = client.Update(Secret{ID: "1"})
+	if err == nil {
+		t.Error("Update() should fail when login fails")
+	}
+	err = client.Delete("1")
+	if err == nil {
+		t.Error("Delete() should fail when login fails")
+	}
+}
+
+func TestCLIBackendEnv(t *testing.T) {
+	ctx := context.Background()
+	f, b := newFakeLpass(t, fakeState{LoggedIn: true, Secrets: []fakeSecret{{ID: "1"}}})
+	b.Home = "/tmp/lpass-home"
+	b.Env = map[string]string{"LPASS_AGENT_TIMEOUT": "3600", "FOO": "bar"}
+	_, err := b.Read(ctx, "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	env := strings.Join(f.state().LastEnv, "\n")
+	for _, v := range []string{"LPASS_HOME=/tmp/lpass-home", "LPASS_AGENT_TIMEOUT=3600", "FOO=bar"} {
+		if !strings.Contains(env, v) {
+			t.Errorf("expected %s in lpass environment", v)
+		}
+	}
+}
@@ -1,13 +1,10 @@
 package api
 
 import (
-	"bytes"
+	"context"
 	"errors"
-	"fmt"
-	"os"
-	"os/exec"
-	"regexp"
-	"strings"
+	"sync"
+	"time"
 )
 
 // Secret describes a Lastpass object.
@@ -24,68 +21,337 @@ type Secret struct {
 	URL             string            `json:"url"`
 	Username        string            `json:"username"`
 	CustomFields    map[string]string `json:"custom_fields"`
+	// NoteType is the secure note template, e.g. "Server", empty for
+	// regular secrets.
+	NoteType string `json:"-"`
+	// ShareReadonly is set when the secret is in a shared folder we can
+	// only read.
+	ShareReadonly bool `json:"-"`
+	// LastPasswordChangeGmt is when the password last changed, in Unix
+	// seconds. Only the native backend knows it, lpass show doesn't print it.
+	LastPasswordChangeGmt string `json:"-"`
 }
 
-// Client is our Lastpass (lpass) wrapper client.
+// Client is our Lastpass wrapper client.
 type Client struct {
 	Username string
 	Password string
+	// OTP is a one-time multifactor code used when logging in.
+	OTP string
+	// TOTPSecret is a base32 seed used to generate the OTP at login time.
+	TOTPSecret string
+	// OutOfBand waits for the login to be approved on another device.
+	OutOfBand bool
+	// Backend is the store used to talk to Lastpass, defaults to the lpass CLI.
+	Backend Backend
+	// MaxWrites limits how many changes run at once, defaults to 1.
+	MaxWrites int
+
+	// loginMu guards loggedIn, set once the backend confirmed the login.
+	loginMu  sync.Mutex
+	loggedIn bool
+
+	// cacheMu guards the vault cached by Read, see cache.go.
+	cacheMu sync.Mutex
+	cache   []Secret
+	stale   map[string]bool
+
+	// queueMu guards the write queue, see queue.go.
+	queueMu sync.Mutex
+	slots   chan struct{}
+	waiting int
+	batch   *syncBatch
+	stats   QueueStats
+}
+
+// Credentials are passed to Backend.Login.
+type Credentials struct {
+	Username  string
+	Password  string
+	OTP       string
+	OutOfBand bool
+}
+
+// Backend is implemented by anything able to store Lastpass secrets.
+type Backend interface {
+	Login(ctx context.Context, creds Credentials) error
+	Create(ctx context.Context, s Secret) (Secret, error)
+	Read(ctx context.Context, id string) ([]Secret, error)
+	// List returns every secret, passwords and notes may be left out.
+	List(ctx context.Context) ([]Secret, error)
+	Update(ctx context.Context, s Secret) error
+	// Rename moves a secret to fullname, a trailing slash names a folder.
+	Rename(ctx context.Context, id, fullname string) error
+	Delete(ctx context.Context, id string) error
 }
 
 func (s *Secret) genCustomFields() {
 	notes := make(map[string]string)
-	if strings.HasPrefix(s.Note, "NoteType:") {
-		splitted := strings.Split(s.Note, "\n")
-		for _, split := range splitted {
-			re := regexp.MustCompile(`:`)
-			s := re.Split(split, 2)
-			if s[0] == "Notes" {
-				break
-			}
-			if len(s) == 2 {
-				notes[s[0]] = s[1]
-			}
-		}
-		// Fix for Notes with multiline. Always last in end of the string.
-		n := strings.Split(s.Note, "\nNotes:")
-		if len(n) == 2 {
-			notes["Notes"] = n[1]
+	if n, ok := ParseNote(s.Note); ok {
+		notes["NoteType"] = n.Type
+		for _, f := range n.Fields {
+			notes[f.Name] = f.Value
 		}
+		notes["Notes"] = n.Notes
 	}
 	s.CustomFields = notes
+	s.NoteType = notes["NoteType"]
 }
 
-func (s *Secret) getTemplate() string {
-	template := fmt.Sprintf(`Name: %s
-URL: %s
-Username: %s 
-Password: %s
-Notes:    # Add notes below this line.
-%s
-`, s.Name, s.URL, s.Username, s.Password, s.Note)
-	return template
+// getTemplate renders the template lpass add and edit read.
+func (s *Secret) getTemplate() (string, error) {
+	if s.NoteType != "" {
+		var fields []NoteField
+		for _, f := range s.noteFields() {
+			fields = append(fields, NoteField{f[0], f[1]})
+		}
+		return FormatTemplate(Template{Name: s.Name, Fields: fields, Notes: s.Note}, s.NoteType)
+	}
+	return FormatTemplate(Template{
+		Name: s.Name,
+		Fields: []NoteField{
+			{"URL", s.URL},
+			{"Username", s.Username},
+			{"Password", s.Password},
+		},
+		Notes: s.Note,
+	}, "")
 }
 
-func (c *Client) login() error {
-	cmd := exec.Command("lpass", "status", "-q")
-	err := cmd.Run()
-	if err != nil {
-		if c.Username == "" {
-			err := errors.New("Not logged in, please run 'lpass login' manually and try again")
+func (c *Client) backend() Backend {
+	if c.Backend == nil {
+		c.Backend = &CLIBackend{}
+	}
+	return c.Backend
+}
+
+// login logs in with the backend once, later calls reuse the session until
+// an operation reports it ended, see checkSession.
+func (c *Client) login(ctx context.Context) error {
+	// concurrent callers wait for the first one to log in
+	c.loginMu.Lock()
+	defer c.loginMu.Unlock()
+	if c.loggedIn {
+		return nil
+	}
+	creds := Credentials{
+		Username:  c.Username,
+		Password:  c.Password,
+		OTP:       c.OTP,
+		OutOfBand: c.OutOfBand,
+	}
+	if c.TOTPSecret != "" {
+		otp, err := TOTP(c.TOTPSecret, time.Now())
+		if err != nil {
 			return err
 		}
-		cmd := exec.Command("lpass", "login", c.Username)
-		var inbuf, errbuf bytes.Buffer
-		cmd.Env = os.Environ()
-		cmd.Env = append(cmd.Env, "LPASS_DISABLE_PINENTRY=1")
-		inbuf.Write([]byte(c.Password))
-		cmd.Stdin = &inbuf
-		cmd.Stderr = &errbuf
-		err := cmd.Run()
+		creds.OTP = otp
+	}
+	err := c.backend().Login(ctx, creds)
+	c.loggedIn = err == nil
+	return err
+}
+
+// checkSession forgets the login when err says the session ended, so the
+// next operation logs in again.
+func (c *Client) checkSession(err error) error {
+	if errors.Is(err, ErrNotLoggedIn) {
+		c.loginMu.Lock()
+		c.loggedIn = false
+		c.loginMu.Unlock()
+	}
+	return err
+}
+
+// Create is used to create a new resource and generate ID.
+func (c *Client) Create(s Secret) (Secret, error) {
+	return c.CreateContext(context.Background(), s)
+}
+
+// CreateContext is like Create, but gives up when ctx is done.
+func (c *Client) CreateContext(ctx context.Context, s Secret) (Secret, error) {
+	err := c.login(ctx)
+	if err != nil {
+		return s, err
+	}
+	if share := shareName(s.Name); share != "" {
+		err = c.checkShareWrite(ctx, share)
 		if err != nil {
-			var err = errors.New(errbuf.String())
-			return err
+			return s, err
+		}
+	}
+	created := s
+	err = c.writeNow(ctx, "create "+s.Name, func(ctx context.Context) error {
+		created, err = c.backend().Create(ctx, s)
+		return err
+	})
+	c.evictCreated(created.ID, err)
+	return created, err
+}
+
+// Read fetches secrets from upstream, a missing secret returns ErrNotFound.
+func (c *Client) Read(id string) ([]Secret, error) {
+	return c.ReadContext(context.Background(), id)
+}
+
+// ReadContext is like Read, but gives up when ctx is done.
+func (c *Client) ReadContext(ctx context.Context, id string) ([]Secret, error) {
+	var secrets []Secret
+	err := c.login(ctx)
+	if err != nil {
+		return secrets, err
+	}
+	secrets, err = c.readID(ctx, id)
+	if err != nil {
+		return secrets, err
+	}
+	for i := range secrets {
+		secrets[i] = normalizeFolder(secrets[i])
+		secrets[i].genCustomFields()
+	}
+	return secrets, nil
+}
+
+// readID reads the secrets with exactly the given ID. lpass show -G also
+// matches other IDs and names containing it, those are left out.
+func (c *Client) readID(ctx context.Context, id string) ([]Secret, error) {
+	found, err := c.cachedRead(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	var secrets []Secret
+	for _, s := range found {
+		if s.ID == id {
+			secrets = append(secrets, s)
+		}
+	}
+	if len(secrets) == 0 {
+		return nil, &Error{Err: ErrNotFound, Message: id}
+	}
+	return secrets, nil
+}
+
+// List returns the secrets in the vault picked by filter, without passwords
+// or notes unless the backend has them at hand.
+func (c *Client) List(filter ListFilter) ([]Secret, error) {
+	return c.ListContext(context.Background(), filter)
+}
+
+// ListContext is like List, but gives up when ctx is done.
+func (c *Client) ListContext(ctx context.Context, filter ListFilter) ([]Secret, error) {
+	err := c.login(ctx)
+	if err != nil {
+		return nil, err
+	}
+	all, err := c.cachedList(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var secrets []Secret
+	for _, s := range all {
+		if s.IsFolder() {
+			continue
+		}
+		s.genCustomFields()
+		ok, err := filter.match(s)
+		if err != nil {
+			return nil, err
 		}
+		if !ok {
+			continue
+		}
+		if filter.needsDetails() && s.LastModifiedGmt == "" {
+			// lpass ls has no note type or timestamps, read them
+			found, err := c.ReadContext(ctx, s.ID)
+			if errors.Is(err, ErrNotFound) {
+				// removed since we listed it
+				continue
+			} else if err != nil {
+				return nil, err
+			}
+			s = found[0]
+		}
+		ok, err = filter.matchDetails(s)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			secrets = append(secrets, s)
+		}
+	}
+	return secrets, nil
+}
+
+// Find reads the only secret picked by sel. No match returns ErrNotFound,
+// more than one match returns ErrAmbiguousName.
+func (c *Client) Find(sel Selector) (Secret, error) {
+	return c.FindContext(context.Background(), sel)
+}
+
+// FindContext is like Find, but gives up when ctx is done.
+func (c *Client) FindContext(ctx context.Context, sel Selector) (Secret, error) {
+	secrets, err := c.ListContext(ctx, ListFilter{})
+	if err != nil {
+		return Secret{}, err
+	}
+	s, err := selectSecret(secrets, sel)
+	if err != nil {
+		return s, err
+	}
+	found, err := c.ReadContext(ctx, s.ID)
+	if err != nil {
+		return s, err
+	}
+	return found[0], nil
+}
+
+// Update is called to update secret with upstream
+func (c *Client) Update(s Secret) error {
+	return c.UpdateContext(context.Background(), s)
+}
+
+// UpdateContext is like Update, but gives up when ctx is done.
+func (c *Client) UpdateContext(ctx context.Context, s Secret) error {
+	err := c.login(ctx)
+	if err != nil {
+		return err
+	}
+	defer c.evict(s.ID)
+	return c.write(ctx, "update "+s.ID, func(ctx context.Context) error {
+		return c.backend().Update(ctx, s)
+	})
+}
+
+// Rename moves a secret to fullname, keeping its ID.
+func (c *Client) Rename(id, fullname string) error {
+	return c.RenameContext(context.Background(), id, fullname)
+}
+
+// RenameContext is like Rename, but gives up when ctx is done.
+func (c *Client) RenameContext(ctx context.Context, id, fullname string) error {
+	err := c.login(ctx)
+	if err != nil {
+		return err
+	}
+	defer c.evict(id)
+	return c.write(ctx, "rename "+id, func(ctx context.Context) error {
+		return c.backend().Rename(ctx, id, fullname)
+	})
+}
+
+// Delete secret in upstream db
+func (c *Client) Delete(id string) error {
+	return c.DeleteContext(context.Background(), id)
+}
+
+// DeleteContext is like Delete, but gives up when ctx is done.
+func (c *Client) DeleteContext(ctx context.Context, id string) error {
+	err := c.login(ctx)
+	if err != nil {
+		return err
 	}
-	return nil
+	defer c.evict(id)
+	return c.write(ctx, "delete "+id, func(ctx context.Context) error {
+		return c.backend().Delete(ctx, id)
+	})
 }
@@ -4,6 +4,7 @@
 package api
 
 import (
+	"context"
 	"os"
 	"os/exec"
 	"testing"
@@ -25,7 +26,7 @@ func TestClient(t *testing.T) {
 		t.Error(err)
 		return
 	}
-	err = client.login()
+	err = client.login(context.Background())
 	if err != nil {
 		t.Error(err)
 	}
@@ -36,10 +37,13 @@ func TestClient(t *testing.T) {
 		Password: "pw",
 		Note:     "ABC\nDEF\nGHJ",
 	}
-	template := s.getTemplate()
+	template, err := s.getTemplate()
+	if err != nil {
+		t.Error(err)
+	}
 	expect := `Name: myintegrationtest
 URL: https://example.com
-Username: user 
+Username: user
 Password: pw
 Notes:    # Add notes below this line.
 ABC
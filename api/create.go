@@ -2,70 +2,100 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
-	"os/exec"
-	"strings"
-	"time"
+	"fmt"
 )
 
-// Create is used to create a new resource and generate ID.
-func (c *Client) Create(s Secret) (Secret, error) {
-	err := c.login()
+// Create adds a new secret with lpass and waits for its ID.
+func (b *CLIBackend) Create(ctx context.Context, s Secret) (Secret, error) {
+	// Remember which secrets already use this name, so we can tell our new
+	// secret apart from them once it is synced.
+	existing, err := b.showName(ctx, s.Name)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return s, err
+	}
+	known := make(map[string]bool)
+	for _, e := range existing {
+		known[e.ID] = true
+	}
+	template, err := s.getTemplate()
 	if err != nil {
 		return s, err
 	}
-	template := s.getTemplate()
-	cmd := exec.Command("lpass", "add", s.Name, "--non-interactive", "--sync=now")
+	// Unlike other changes creates always sync straight away, not with
+	// syncFlag: the ID only exists once Lastpass accepted the secret.
+	args := []string{"add", s.Name, "--non-interactive", "--sync=now"}
+	if s.NoteType != "" {
+		noteType, ok := NoteTypes[s.NoteType]
+		if !ok {
+			return s, fmt.Errorf("unsupported note type %q", s.NoteType)
+		}
+		args = append(args, "--note-type="+noteType)
+	}
+	cmd := b.command(ctx, args...)
 	var inbuf, errbuf bytes.Buffer
 	inbuf.Write([]byte(template))
 	cmd.Stdin = &inbuf
 	cmd.Stderr = &errbuf
 	err = cmd.Run()
 	if err != nil {
-		var err = errors.New(errbuf.String())
-		return s, err
+		return s, commandError(ctx, errbuf.String())
 	}
-	var outbuf bytes.Buffer
-	var secrets []Secret
+	var created Secret
 	// because of the ridiculous way lpass sync works we will need to retry until we get our ID.
 	// see open issue at https://github.com/lastpass/lastpass-cli/issues/450
-	for i := 0; i < 10; i++ {
-		time.Sleep(time.Second * 2)
+	done, err := b.Retry.retry(ctx, func() (bool, error) {
 		errbuf.Reset()
-		outbuf.Reset()
-		cmd = exec.Command("lpass", "sync")
+		cmd := b.command(ctx, "sync")
 		cmd.Stderr = &errbuf
-		err = cmd.Run()
+		err := cmd.Run()
 		if err != nil {
-			var err = errors.New(errbuf.String())
-			return s, err
+			return false, commandError(ctx, errbuf.String())
 		}
-		cmd = exec.Command("lpass", "show", "--sync=now", s.Name, "--json", "-x")
-		cmd.Stdout = &outbuf
-		cmd.Stderr = &errbuf
-		err = cmd.Run()
-		if err != nil {
-			if !strings.Contains(errbuf.String(), "Could not find specified account") {
-				var err = errors.New(errbuf.String())
-				return s, err
-			}
-			continue
+		secrets, err := b.showName(ctx, s.Name)
+		if errors.Is(err, ErrNotFound) {
+			return false, nil
+		} else if err != nil {
+			return false, err
 		}
-		err = json.Unmarshal(outbuf.Bytes(), &secrets)
-		if err != nil {
-			return s, err
+		var added []Secret
+		for _, secret := range secrets {
+			if !known[secret.ID] {
+				added = append(added, secret)
+			}
 		}
-		if len(secrets) > 1 {
-			err := errors.New("more than one secret with same name, unable to determine ID")
-			return s, err
+		if len(added) > 1 {
+			return false, &Error{Err: ErrAmbiguousName, Message: "more than one new secret named " + s.Name + ", unable to determine ID"}
 		}
-		if secrets[0].ID == "0" {
+		if len(added) == 0 || added[0].ID == "0" {
 			// sync is still not done with upstream.
-			continue
+			return false, nil
 		}
-		return secrets[0], nil
+		created = added[0]
+		return true, nil
+	})
+	if err != nil {
+		return s, err
+	}
+	if !done {
+		return s, &Error{Err: ErrSyncTimeout, Message: "unable to create new secret " + s.Name}
+	}
+	return created, nil
+}
+
+// showName returns all secrets matching name exactly.
+func (b *CLIBackend) showName(ctx context.Context, name string) ([]Secret, error) {
+	var secrets []Secret
+	var outbuf, errbuf bytes.Buffer
+	cmd := b.command(ctx, "show", "--sync=now", name, "--json", "-x")
+	cmd.Stdout = &outbuf
+	cmd.Stderr = &errbuf
+	err := cmd.Run()
+	if err != nil {
+		return secrets, commandError(ctx, errbuf.String())
 	}
-	err = errors.New("timeout, unable to create new secret")
-	return s, err
+	err = json.Unmarshal(outbuf.Bytes(), &secrets)
+	return secrets, err
 }
@@ -0,0 +1,172 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCLIBackendCreate(t *testing.T) {
+	f, b := newFakeLpass(t, fakeState{LoggedIn: true, LastID: 100, HiddenShows: 2, ZeroIDShows: 2})
+	client := Client{Backend: b}
+	s, err := client.Create(Secret{
+		Name:     "Folder/mysecret",
+		URL:      "https://example.com",
+		Username: "user",
+		Password: "pw",
+		Note:     "ABC\nDEF\n",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.ID != "101" || s.Fullname != "Folder/mysecret" || s.Username != "user" {
+		t.Errorf("Create() returned unexpected secret: %+v", s)
+	}
+	state := f.state()
+	if len(state.Secrets) != 1 || state.Secrets[0].Note != "ABC\nDEF" || state.Secrets[0].URL != "https://example.com" {
+		t.Errorf("unexpected secret stored: %+v", state.Secrets)
+	}
+	var shows int
+	for _, call := range state.Calls {
+		if strings.HasPrefix(call, "show") {
+			shows++
+		}
+	}
+	// one lookup of existing secrets, then until synced
+	if shows != 6 {
+		t.Errorf("expected 6 show attempts, got %d", shows)
+	}
+}
+
+func TestCLIBackendCreateDuplicateName(t *testing.T) {
+	ctx := context.Background()
+	existing := []fakeSecret{{ID: "1", Fullname: "mysecret"}, {ID: "2", Fullname: "mysecret"}}
+	_, b := newFakeLpass(t, fakeState{LoggedIn: true, LastID: 2, Secrets: existing, ZeroIDShows: 1})
+	s, err := b.Create(ctx, Secret{Name: "mysecret", Password: "pw"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.ID != "3" || s.Password != "pw" {
+		t.Errorf("expected the new secret, got %+v", s)
+	}
+}
+
+func TestCLIBackendCreateErrors(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name  string
+		state fakeState
+		err   error
+		msg   string
+	}{
+		{
+			name:  "add fails",
+			state: fakeState{LoggedIn: true, Fail: map[string]string{"add": "Error: add failed"}},
+			msg:   "Error: add failed",
+		},
+		{
+			name:  "sync fails",
+			state: fakeState{LoggedIn: true, Fail: map[string]string{"sync": "Error: sync failed"}},
+			msg:   "Error: sync failed",
+		},
+		{
+			name:  "invalid show output",
+			state: fakeState{LoggedIn: true, Output: map[string]string{"show": "[{"}},
+			msg:   "unexpected end of JSON input",
+		},
+		{
+			name:  "pre-check fails",
+			state: fakeState{LoggedIn: true, Fail: map[string]string{"show": "Error: show failed"}},
+			msg:   "Error: show failed",
+		},
+		{
+			name:  "concurrent duplicate",
+			state: fakeState{LoggedIn: true, DuplicateAdds: 1},
+			err:   ErrAmbiguousName,
+		},
+		{
+			name:  "never synced",
+			state: fakeState{LoggedIn: true, ZeroIDShows: 1 << 30},
+			err:   ErrSyncTimeout,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, b := newFakeLpass(t, tt.state)
+			_, err := b.Create(ctx, Secret{Name: "mysecret"})
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if tt.err != nil && !errors.Is(err, tt.err) {
+				t.Errorf("expected error %v, got %v", tt.err, err)
+			}
+			if !strings.Contains(err.Error(), tt.msg) {
+				t.Errorf("expected %q in error, got %v", tt.msg, err)
+			}
+		})
+	}
+}
+
+func TestCLIBackendCreateCancel(t *testing.T) {
+	_, b := newFakeLpass(t, fakeState{LoggedIn: true, ZeroIDShows: 100})
+	b.Retry = RetryPolicy{InitialDelay: time.Hour, MaxDelay: time.Hour, Deadline: 2 * time.Hour}
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := b.Create(ctx, Secret{Name: "mysecret"})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline exceeded, got %v", err)
+	}
+	if time.Since(start) > 10*time.Second {
+		t.Error("Create() did not stop waiting for sync when ctx was done")
+	}
+}
+
+func TestCLIBackendCreateSecureNote(t *testing.T) {
+	f, b := newFakeLpass(t, fakeState{LoggedIn: true})
+	client := Client{Backend: b}
+	s, err := client.Create(Secret{
+		Name:         "myserver",
+		Username:     "root",
+		Password:     "pw",
+		Note:         "ABC\nDEF\n",
+		NoteType:     "Server",
+		CustomFields: map[string]string{"Hostname": "example.com", "Port": "22"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	state := f.state()
+	var add string
+	for _, call := range state.Calls {
+		if strings.HasPrefix(call, "add") {
+			add = call
+		}
+	}
+	if !strings.Contains(add, "--note-type=server") {
+		t.Errorf("expected note type passed to lpass add, got %q", add)
+	}
+	secrets, err := client.Read(s.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := secrets[0]
+	if got.NoteType != "Server" || got.URL != "http://sn" {
+		t.Errorf("expected a server note, got %+v", got)
+	}
+	want := map[string]string{"Hostname": "example.com", "Port": "22", "Username": "root", "Password": "pw"}
+	for k, v := range want {
+		if got.CustomFields[k] != v {
+			t.Errorf("expected field %s=%q, got %q", k, v, got.CustomFields[k])
+		}
+	}
+	if got.CustomFields["Notes"] != "ABC\nDEF\n" {
+		t.Errorf("multiline notes not preserved, got %q", got.CustomFields["Notes"])
+	}
+	_, err = b.Create(context.Background(), Secret{Name: "foo", NoteType: "Unknown"})
+	if err == nil || !strings.Contains(err.Error(), "unsupported note type") {
+		t.Errorf("expected unsupported note type error, got %v", err)
+	}
+}
@@ -0,0 +1,153 @@
+package api
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// DatabaseCredential is a "Database" secure note.
+type DatabaseCredential struct {
+	// Type is the kind of database, e.g. "PostgreSQL".
+	Type     string
+	Hostname string
+	// Port is 0 when not set.
+	Port     int
+	Database string
+	Username string
+	Password string
+	// SID is the Oracle system identifier.
+	SID   string
+	Alias string
+	Note  string
+}
+
+// Secret returns the secure note of the credential, named fullname.
+func (c DatabaseCredential) Secret(fullname string) Secret {
+	port := ""
+	if c.Port != 0 {
+		port = strconv.Itoa(c.Port)
+	}
+	return Secret{
+		Name:     fullname,
+		NoteType: "Database",
+		Username: c.Username,
+		Password: c.Password,
+		Note:     c.Note,
+		CustomFields: map[string]string{
+			"Type":     c.Type,
+			"Hostname": c.Hostname,
+			"Port":     port,
+			"Database": c.Database,
+			"SID":      c.SID,
+			"Alias":    c.Alias,
+		},
+	}
+}
+
+// DatabaseCredentialFromSecret reads a "Database" secure note.
+func DatabaseCredentialFromSecret(s Secret) (DatabaseCredential, error) {
+	if s.NoteType != "Database" {
+		return DatabaseCredential{}, fmt.Errorf("%s (%s) is not a Database note, but %q", s.Fullname, s.ID, s.NoteType)
+	}
+	f := s.CustomFields
+	c := DatabaseCredential{
+		Type:     f["Type"],
+		Hostname: f["Hostname"],
+		Database: f["Database"],
+		Username: f["Username"],
+		Password: f["Password"],
+		SID:      f["SID"],
+		Alias:    f["Alias"],
+		Note:     f["Notes"],
+	}
+	if f["Port"] != "" {
+		port, err := strconv.Atoi(f["Port"])
+		if err != nil {
+			return c, fmt.Errorf("invalid port %q in %s (%s)", f["Port"], s.Fullname, s.ID)
+		}
+		c.Port = port
+	}
+	return c, nil
+}
+
+// ServerCredential is a "Server" secure note.
+type ServerCredential struct {
+	Hostname string
+	Username string
+	Password string
+	Note     string
+}
+
+// Secret returns the secure note of the credential, named fullname.
+func (c ServerCredential) Secret(fullname string) Secret {
+	return Secret{
+		Name:         fullname,
+		NoteType:     "Server",
+		Username:     c.Username,
+		Password:     c.Password,
+		Note:         c.Note,
+		CustomFields: map[string]string{"Hostname": c.Hostname},
+	}
+}
+
+// ServerCredentialFromSecret reads a "Server" secure note.
+func ServerCredentialFromSecret(s Secret) (ServerCredential, error) {
+	if s.NoteType != "Server" {
+		return ServerCredential{}, fmt.Errorf("%s (%s) is not a Server note, but %q", s.Fullname, s.ID, s.NoteType)
+	}
+	f := s.CustomFields
+	return ServerCredential{
+		Hostname: f["Hostname"],
+		Username: f["Username"],
+		Password: f["Password"],
+		Note:     f["Notes"],
+	}, nil
+}
+
+// apiCredentialType is the note type of API credentials. Lastpass has no
+// template for it, see APICredential.
+const apiCredentialType = "API Credential"
+
+// APICredential is an API key stored as a secure note. Lastpass has no
+// template for API keys and lpass can't add notes of a type it doesn't know,
+// so the note is a plain secure note whose text lists the fields the way
+// template notes do, and Lastpass shows that text as is.
+type APICredential struct {
+	// Endpoint is the URL of the API.
+	Endpoint  string
+	KeyID     string
+	SecretKey string
+	// Expires is when the key expires, free text.
+	Expires string
+	Note    string
+}
+
+// Secret returns the secure note of the credential, named fullname.
+func (c APICredential) Secret(fullname string) (Secret, error) {
+	var fields []NoteField
+	for _, f := range []NoteField{{"Endpoint", c.Endpoint}, {"Key ID", c.KeyID}, {"Secret", c.SecretKey}, {"Expires", c.Expires}} {
+		if f.Value != "" {
+			fields = append(fields, f)
+		}
+	}
+	note, err := FormatNote(Note{Type: apiCredentialType, Fields: append([]NoteField{{"Language", "en-US"}}, fields...), Notes: c.Note})
+	if err != nil {
+		return Secret{}, err
+	}
+	return Secret{Name: fullname, URL: noteURL, Note: note}, nil
+}
+
+// APICredentialFromSecret reads an API credential note.
+func APICredentialFromSecret(s Secret) (APICredential, error) {
+	if s.NoteType != apiCredentialType {
+		return APICredential{}, fmt.Errorf("%s (%s) is not an %s note, but %q", s.Fullname, s.ID, apiCredentialType, s.NoteType)
+	}
+	f := s.CustomFields
+	return APICredential{
+		Endpoint:  f["Endpoint"],
+		KeyID:     f["Key ID"],
+		SecretKey: f["Secret"],
+		Expires:   f["Expires"],
+		Note:      f["Notes"],
+	}, nil
+}
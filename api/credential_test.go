@@ -0,0 +1,118 @@
+package api
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDatabaseCredentialRoundTrip(t *testing.T) {
+	client := &Client{Backend: &MemoryBackend{}}
+	want := DatabaseCredential{
+		Type:     "PostgreSQL",
+		Hostname: "db.example.com",
+		Port:     5432,
+		Database: "app",
+		Username: "app",
+		Password: "hunter2",
+		Alias:    "primary",
+		Note:     "line 1\nline 2",
+	}
+	s, err := client.Create(want.Secret("Infra/db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	secrets, err := client.Read(s.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if secrets[0].Fullname != "Infra/db" {
+		t.Errorf("unexpected fullname %q", secrets[0].Fullname)
+	}
+	got, err := DatabaseCredentialFromSecret(secrets[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+	_, err = ServerCredentialFromSecret(secrets[0])
+	if err == nil {
+		t.Error("expected an error reading a Database note as a Server note")
+	}
+}
+
+func TestDatabaseCredentialInvalidPort(t *testing.T) {
+	s := Secret{NoteType: "Database", CustomFields: map[string]string{"Port": "postgres"}}
+	_, err := DatabaseCredentialFromSecret(s)
+	if err == nil {
+		t.Error("expected an error for a non-numeric port")
+	}
+}
+
+func TestServerCredentialRoundTrip(t *testing.T) {
+	_, b := newFakeLpass(t, fakeState{LoggedIn: true})
+	client := &Client{Backend: b}
+	want := ServerCredential{Hostname: "example.com", Username: "root", Password: "pw", Note: "hello"}
+	s, err := client.Create(want.Secret("web"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	secrets, err := client.Read(s.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := ServerCredentialFromSecret(secrets[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestAPICredentialRoundTrip(t *testing.T) {
+	_, b := newFakeLpass(t, fakeState{LoggedIn: true})
+	for _, client := range []*Client{{Backend: &MemoryBackend{}}, {Backend: b}} {
+		want := APICredential{
+			Endpoint:  "https://api.example.com",
+			KeyID:     "AKIA",
+			SecretKey: "s3cr3t",
+			Expires:   "2027-01-01",
+			Note:      "line 1\nline 2",
+		}
+		s, err := want.Secret("Infra/api")
+		if err != nil {
+			t.Fatal(err)
+		}
+		s, err = client.Create(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		secrets, err := client.Read(s.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if secrets[0].URL != noteURL {
+			t.Errorf("%T: expected a secure note, got URL %q", client.Backend, secrets[0].URL)
+		}
+		got, err := APICredentialFromSecret(secrets[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		got.Note = strings.TrimSuffix(got.Note, "\n")
+		if got != want {
+			t.Errorf("%T: got %+v, want %+v", client.Backend, got, want)
+		}
+		_, err = ServerCredentialFromSecret(secrets[0])
+		if err == nil {
+			t.Errorf("%T: expected an error reading an API credential as a Server note", client.Backend)
+		}
+	}
+}
+
+func TestAPICredentialInvalidField(t *testing.T) {
+	_, err := APICredential{SecretKey: "line 1\nKey ID:x"}.Secret("api")
+	if err == nil {
+		t.Error("expected an error for a secret that would start another field")
+	}
+}
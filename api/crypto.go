@@ -0,0 +1,124 @@
+package api
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// makeKey derives the vault encryption key from the login credentials.
+func makeKey(username, password string, iterations int) []byte {
+	if iterations == 1 {
+		sum := sha256.Sum256([]byte(username + password))
+		return sum[:]
+	}
+	return pbkdf2.Key([]byte(password), []byte(username), iterations, 32, sha256.New)
+}
+
+// makeLoginHash derives the hash sent to Lastpass instead of the password.
+func makeLoginHash(key []byte, password string, iterations int) string {
+	if iterations == 1 {
+		sum := sha256.Sum256([]byte(hex.EncodeToString(key) + password))
+		return hex.EncodeToString(sum[:])
+	}
+	return hex.EncodeToString(pbkdf2.Key(key, []byte(password), 1, 32, sha256.New))
+}
+
+// decryptItem decrypts a raw vault field, which is either AES-256-CBC
+// prefixed with '!' and the IV, or plain AES-256-ECB.
+func decryptItem(data, key []byte) (string, error) {
+	if len(data) == 0 {
+		return "", nil
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return "", err
+	}
+	var plain []byte
+	if data[0] == '!' && len(data)%aes.BlockSize == 1 && len(data) > aes.BlockSize*2 {
+		iv := data[1 : 1+aes.BlockSize]
+		ciphertext := data[1+aes.BlockSize:]
+		plain = make([]byte, len(ciphertext))
+		cipher.NewCBCDecrypter(block, iv).CryptBlocks(plain, ciphertext)
+	} else {
+		if len(data)%aes.BlockSize != 0 {
+			return "", errors.New("invalid encrypted field length")
+		}
+		plain = make([]byte, len(data))
+		for i := 0; i < len(data); i += aes.BlockSize {
+			block.Decrypt(plain[i:i+aes.BlockSize], data[i:i+aes.BlockSize])
+		}
+	}
+	return unpad(plain)
+}
+
+// encryptItem encrypts a value with AES-256-CBC in the base64 form
+// Lastpass expects when pushing changes ("!" + iv + "|" + ciphertext).
+func encryptItem(value string, key []byte) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return "", err
+	}
+	iv := make([]byte, aes.BlockSize)
+	if _, err := rand.Read(iv); err != nil {
+		return "", err
+	}
+	plain := pad([]byte(value))
+	ciphertext := make([]byte, len(plain))
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(ciphertext, plain)
+	return "!" + base64.StdEncoding.EncodeToString(iv) + "|" + base64.StdEncoding.EncodeToString(ciphertext), nil
+}
+
+// decryptBase64Item is the inverse of encryptItem.
+func decryptBase64Item(value string, key []byte) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+	if value[0] != '!' {
+		data, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return "", err
+		}
+		return decryptItem(data, key)
+	}
+	parts := bytes.SplitN([]byte(value[1:]), []byte("|"), 2)
+	if len(parts) != 2 {
+		return "", errors.New("invalid encrypted field")
+	}
+	iv, err := base64.StdEncoding.DecodeString(string(parts[0]))
+	if err != nil {
+		return "", err
+	}
+	ciphertext, err := base64.StdEncoding.DecodeString(string(parts[1]))
+	if err != nil {
+		return "", err
+	}
+	data := append([]byte{'!'}, iv...)
+	return decryptItem(append(data, ciphertext...), key)
+}
+
+func pad(data []byte) []byte {
+	n := aes.BlockSize - len(data)%aes.BlockSize
+	return append(data, bytes.Repeat([]byte{byte(n)}, n)...)
+}
+
+func unpad(data []byte) (string, error) {
+	if len(data) == 0 {
+		return "", errors.New("invalid padding")
+	}
+	n := int(data[len(data)-1])
+	if n == 0 || n > aes.BlockSize || n > len(data) {
+		return "", errors.New("invalid padding, wrong key?")
+	}
+	return string(data[:len(data)-n]), nil
+}
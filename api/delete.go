@@ -2,28 +2,17 @@ package api
 
 import (
 	"bytes"
-	"errors"
-	"os/exec"
-	"strings"
+	"context"
 )
 
-// Delete secret in upstream db
-func (c *Client) Delete(id string) error {
-	err := c.login()
-	if err != nil {
-		return err
-	}
+// Delete removes a secret with lpass rm.
+func (b *CLIBackend) Delete(ctx context.Context, id string) error {
 	var errbuf bytes.Buffer
-	cmd := exec.Command("lpass", "rm", id, "--sync=now")
+	cmd := b.command(ctx, "rm", id, b.syncFlag(ctx))
 	cmd.Stderr = &errbuf
-	err = cmd.Run()
+	err := cmd.Run()
 	if err != nil {
-		// Make sure the secret is not removed manually.
-		if strings.Contains(errbuf.String(), "Could not find specified account") {
-			return nil
-		}
-		var err = errors.New(errbuf.String())
-		return err
+		return commandError(ctx, errbuf.String())
 	}
 	return nil
 }
@@ -0,0 +1,33 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestCLIBackendDelete(t *testing.T) {
+	ctx := context.Background()
+	f, b := newFakeLpass(t, fakeState{LoggedIn: true, Secrets: []fakeSecret{{ID: "1", Fullname: "mysecret"}}})
+	err := b.Delete(ctx, "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(f.state().Secrets) != 0 {
+		t.Error("secret still exists after Delete()")
+	}
+	// the secret is already gone, e.g. removed manually
+	err = b.Delete(ctx, "1")
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected not found error, got %v", err)
+	}
+}
+
+func TestCLIBackendDeleteError(t *testing.T) {
+	ctx := context.Background()
+	_, b := newFakeLpass(t, fakeState{LoggedIn: true, Fail: map[string]string{"rm": "Error: network down"}})
+	err := b.Delete(ctx, "1")
+	if err == nil || err.Error() != "Error: network down" || errors.Is(err, ErrNotFound) {
+		t.Errorf("expected lpass stderr as error, got %v", err)
+	}
+}
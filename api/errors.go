@@ -0,0 +1,115 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Errors returned by Client, check them with errors.Is.
+var (
+	ErrNotFound      = errors.New("secret not found")
+	ErrNotLoggedIn   = errors.New("not logged in to Lastpass")
+	ErrAmbiguousName = errors.New("more than one secret with same name")
+	ErrSyncTimeout   = errors.New("timeout waiting for Lastpass to sync")
+	ErrAuthFailed    = errors.New("Lastpass login failed")
+	ErrRateLimited   = errors.New("rate limited by Lastpass")
+	ErrNotEmpty      = errors.New("folder not empty")
+	ErrUnsupported   = errors.New("not supported by this backend")
+	ErrReadOnly      = errors.New("read-only shared folder")
+	ErrForbidden     = errors.New("not allowed by Lastpass")
+
+	// ErrMFARequired and ErrInvalidOTP are both also ErrAuthFailed.
+	ErrMFARequired = fmt.Errorf("MFA required: %w", ErrAuthFailed)
+	ErrInvalidOTP  = fmt.Errorf("invalid OTP: %w", ErrAuthFailed)
+)
+
+// Error wraps one of the sentinel errors above together with the message
+// returned by lpass or the Lastpass API. Err is nil when the failure could
+// not be classified.
+type Error struct {
+	Err     error
+	Message string
+}
+
+func (e *Error) Error() string {
+	if e.Err == nil {
+		return e.Message
+	}
+	if e.Message == "" {
+		return e.Err.Error()
+	}
+	return e.Err.Error() + ": " + e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// lpassError classifies an error message from lpass or the Lastpass API.
+func lpassError(msg string) error {
+	msg = strings.TrimSpace(msg)
+	lower := strings.ToLower(msg)
+	var err error
+	switch {
+	case strings.Contains(lower, "could not find specified account"),
+		strings.Contains(lower, "unable to find shared folder"),
+		strings.Contains(lower, "unable to find user"):
+		err = ErrNotFound
+	case strings.Contains(lower, "could not find decryption key"),
+		strings.Contains(lower, "not logged in"),
+		strings.Contains(lower, "session expired"):
+		err = ErrNotLoggedIn
+	case strings.Contains(lower, "unable to access user list"),
+		strings.Contains(lower, "permission denied"):
+		err = ErrForbidden
+	case strings.Contains(lower, "multiple matches found"):
+		err = ErrAmbiguousName
+	case strings.Contains(lower, "rate limit"),
+		strings.Contains(lower, "too many"),
+		strings.Contains(lower, "429"):
+		err = ErrRateLimited
+	}
+	return &Error{Err: err, Message: msg}
+}
+
+// Causes Lastpass gives for a login that needs, or got a wrong, second factor.
+var (
+	mfaRequiredCauses = []string{"googleauthrequired", "microsoftauthrequired", "otprequired", "outofbandrequired", "yubikeyrequired", "multifactorrequired"}
+	invalidOTPCauses  = []string{"googleauthfailed", "microsoftauthfailed", "otpfailed", "yubikeyfailed", "multifactorresponsefailed"}
+)
+
+// loginError classifies a failed login, multifactor failures get their own
+// errors so we can tell the user what to configure. The native backend gets
+// the cause from Lastpass, lpass only prints the message so it is matched on
+// the phrases Lastpass uses for multifactor failures.
+func loginError(cause, msg string) error {
+	if cause != "" {
+		msg = cause + ": " + msg
+	}
+	err := lpassError(msg).(*Error)
+	if err.Err == ErrRateLimited {
+		return err
+	}
+	lower := strings.ToLower(msg)
+	switch {
+	case stringInSlice(cause, invalidOTPCauses),
+		cause == "" && (strings.Contains(lower, "code is incorrect") || strings.Contains(lower, "invalid code")):
+		err.Err = ErrInvalidOTP
+	case stringInSlice(cause, mfaRequiredCauses),
+		cause == "" && strings.Contains(lower, "authentication required"):
+		err.Err = ErrMFARequired
+	default:
+		err.Err = ErrAuthFailed
+	}
+	return err
+}
+
+func stringInSlice(s string, list []string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,58 @@
+package api
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestLpassError(t *testing.T) {
+	tests := map[string]error{
+		"Error: Could not find specified account(s).":                                         ErrNotFound,
+		"Error: Could not find decryption key. Perhaps you need to login with `lpass login`.": ErrNotLoggedIn,
+		"Multiple matches found.":                                                             ErrAmbiguousName,
+		"Error: Too many login attempts, try again later.":                                    ErrRateLimited,
+		"Error: Server error 429":                                                             ErrRateLimited,
+		"Error: Unable to access user list for share Shared-Infra":                            ErrForbidden,
+		"Error: something else":                                                               nil,
+	}
+	for msg, want := range tests {
+		err := lpassError(msg)
+		var e *Error
+		if !errors.As(err, &e) {
+			t.Fatalf("expected *Error, got %T", err)
+		}
+		if e.Err != want {
+			t.Errorf("lpassError(%q) = %v, want %v", msg, e.Err, want)
+		}
+		if e.Message != msg {
+			t.Errorf("expected original message to be kept, got %q", e.Message)
+		}
+	}
+}
+
+func TestLoginError(t *testing.T) {
+	tests := []struct {
+		cause, msg string
+		want       error
+	}{
+		{"", "Error: Invalid username or password.", ErrAuthFailed},
+		{"", "Error: Google Authenticator authentication required!", ErrMFARequired},
+		{"outofbandrequired", "Multifactor authentication required", ErrMFARequired},
+		{"otprequired", "Please enter your one-time password", ErrMFARequired},
+		{"", "Error: Google Authenticator code is incorrect.", ErrInvalidOTP},
+		{"googleauthfailed", "Invalid code", ErrInvalidOTP},
+		{"", "Error: Too many login attempts", ErrRateLimited},
+		// wrong master passwords are not multifactor errors, whatever the wording
+		{"unknownpassword", "Password is incorrect.", ErrAuthFailed},
+		{"unknownemail", "Email address required.", ErrAuthFailed},
+	}
+	for _, tt := range tests {
+		err := loginError(tt.cause, tt.msg)
+		if !errors.Is(err, tt.want) {
+			t.Errorf("loginError(%q, %q) = %v, want %v", tt.cause, tt.msg, err, tt.want)
+		}
+		if tt.want == ErrAuthFailed && (errors.Is(err, ErrMFARequired) || errors.Is(err, ErrInvalidOTP)) {
+			t.Errorf("loginError(%q, %q) is not a multifactor error, got %v", tt.cause, tt.msg, err)
+		}
+	}
+}
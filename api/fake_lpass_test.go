@@ -0,0 +1,565 @@
+package api
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// The api tests run the test binary itself as a fake lpass, see TestMain.
+// The fake keeps its state in a JSON file pointed to by FAKE_LPASS_STATE.
+const fakeLpassEnv = "FAKE_LPASS_STATE"
+
+func TestMain(m *testing.M) {
+	if path := os.Getenv(fakeLpassEnv); path != "" {
+		os.Exit(runFakeLpass(path, os.Args[1:]))
+	}
+	os.Exit(m.Run())
+}
+
+// fakeState is the vault and scripted behaviour of the fake lpass.
+type fakeState struct {
+	LoggedIn bool
+	Username string
+	Password string
+	// OTP is the multifactor code login requires, if any.
+	OTP string
+	// TOTPSecret makes login require the current code for this seed.
+	TOTPSecret string
+	Secrets    []fakeSecret
+	LastID     int
+	// HiddenShows is how many times show can't find a newly added secret.
+	HiddenShows int
+	// ZeroIDShows is how many times show returns ID "0" for a new secret.
+	ZeroIDShows int
+	// PendingID is the last added secret, affected by the two above.
+	PendingID string
+	// DuplicateAdds makes add create extra secrets with the same name, as
+	// if someone else created them at the same time.
+	DuplicateAdds int
+	// Shares maps shared folders to their members.
+	Shares map[string][]ShareUser
+	// Fail maps a lpass command to the stderr it should fail with.
+	Fail map[string]string
+	// Output maps a lpass command to a canned stdout.
+	Output map[string]string
+	// Hang makes a lpass command hang, until it is killed.
+	Hang map[string]bool
+	// Calls records every invocation with its environment flags.
+	Calls []string
+	// LastEnv is the environment of the last invocation.
+	LastEnv []string
+}
+
+type fakeSecret struct {
+	ID       string
+	Fullname string
+	Username string
+	Password string
+	URL      string
+	Note     string
+	Share    string
+	// ShareReadonly is printed as a JSON bool when set.
+	ShareReadonly bool
+	// Attachments maps attachment IDs, e.g. "att-1", to their files.
+	Attachments map[string]fakeAttachment
+}
+
+type fakeAttachment struct {
+	Filename string
+	Content  []byte
+}
+
+// fakeLpass is a handle used by tests to script and inspect the fake.
+type fakeLpass struct {
+	t    *testing.T
+	path string
+}
+
+// fakeRetry bounds waiting for the fake to sync by attempts, each one runs
+// the test binary again which is too slow for a wall-clock deadline under
+// the race detector.
+var fakeRetry = RetryPolicy{InitialDelay: time.Millisecond, MaxDelay: time.Millisecond, Deadline: time.Hour, Attempts: 20}
+
+// newFakeLpass points a CLIBackend at the fake lpass with the given state.
+func newFakeLpass(t *testing.T, state fakeState) (*fakeLpass, *CLIBackend) {
+	f := &fakeLpass{t: t, path: filepath.Join(t.TempDir(), "state.json")}
+	f.save(state)
+	os.Setenv(fakeLpassEnv, f.path)
+	t.Cleanup(func() { os.Unsetenv(fakeLpassEnv) })
+	return f, &CLIBackend{Path: os.Args[0], Retry: fakeRetry}
+}
+
+func (f *fakeLpass) state() fakeState {
+	state, err := loadFakeState(f.path)
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	return state
+}
+
+func (f *fakeLpass) save(state fakeState) {
+	err := saveFakeState(f.path, state)
+	if err != nil {
+		f.t.Fatal(err)
+	}
+}
+
+func loadFakeState(path string) (fakeState, error) {
+	var state fakeState
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return state, err
+	}
+	err = json.Unmarshal(data, &state)
+	return state, err
+}
+
+func saveFakeState(path string, state fakeState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0600)
+}
+
+func runFakeLpass(path string, args []string) int {
+	state, err := loadFakeState(path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	call := strings.Join(args, " ")
+	if os.Getenv("LPASS_DISABLE_PINENTRY") == "1" {
+		call += " [nopinentry]"
+	}
+	state.Calls = append(state.Calls, call)
+	state.LastEnv = os.Environ()
+	code := fakeCommand(&state, args)
+	err = saveFakeState(path, state)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	return code
+}
+
+func fakeCommand(state *fakeState, args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "Usage: lpass [--version, -v] [--help, -h]")
+		return 1
+	}
+	if state.Hang[args[0]] {
+		time.Sleep(time.Minute)
+	}
+	if msg, ok := state.Fail[args[0]]; ok {
+		fmt.Fprint(os.Stderr, msg)
+		return 1
+	}
+	if out, ok := state.Output[args[0]]; ok {
+		fmt.Print(out)
+		return 0
+	}
+	var flags []string
+	var positional []string
+	for _, arg := range args[1:] {
+		if strings.HasPrefix(arg, "-") {
+			flags = append(flags, arg)
+		} else {
+			positional = append(positional, arg)
+		}
+	}
+	hasFlag := func(name string) bool {
+		for _, f := range flags {
+			if f == name {
+				return true
+			}
+		}
+		return false
+	}
+	if args[0] != "status" && args[0] != "login" && !state.LoggedIn {
+		fmt.Fprint(os.Stderr, "Error: Could not find decryption key. Perhaps you need to login with `lpass login`.")
+		return 1
+	}
+	switch args[0] {
+	case "status":
+		if !state.LoggedIn {
+			if !hasFlag("-q") {
+				fmt.Println("Not logged in.")
+			}
+			return 1
+		}
+		if !hasFlag("-q") {
+			fmt.Printf("Logged in as %s.\n", state.Username)
+		}
+		return 0
+	case "login":
+		stdin := bufio.NewReader(os.Stdin)
+		password, _ := stdin.ReadString('\n')
+		if len(positional) != 1 || positional[0] != state.Username || strings.TrimSpace(password) != state.Password {
+			fmt.Fprint(os.Stderr, "Error: Invalid username or password.")
+			return 1
+		}
+		if state.OTP != "" || state.TOTPSecret != "" {
+			otp, _ := stdin.ReadString('\n')
+			otp = strings.TrimSpace(otp)
+			if otp == "" {
+				fmt.Fprint(os.Stderr, "Error: Google Authenticator authentication required!")
+				return 1
+			}
+			valid := otp == state.OTP
+			if state.TOTPSecret != "" {
+				// accept the previous code too, in case we crossed a step
+				now, _ := TOTP(state.TOTPSecret, time.Now())
+				prev, _ := TOTP(state.TOTPSecret, time.Now().Add(-30*time.Second))
+				valid = otp == now || otp == prev
+			}
+			if !valid {
+				fmt.Fprint(os.Stderr, "Error: Google Authenticator code is incorrect.")
+				return 1
+			}
+		}
+		state.LoggedIn = true
+		return 0
+	case "sync":
+		return 0
+	case "add":
+		s := parseFakeTemplate(os.Stdin, fakeNoteType(flags))
+		s.Fullname = positional[0]
+		s.Share = shareName(s.Fullname)
+		for i := 0; i <= state.DuplicateAdds; i++ {
+			state.LastID++
+			s.ID = strconv.Itoa(state.LastID)
+			state.Secrets = append(state.Secrets, s)
+		}
+		state.PendingID = s.ID
+		return 0
+	case "ls":
+		return fakeLs(state, flags)
+	case "share":
+		return fakeShare(state, positional, flags)
+	case "show":
+		for _, f := range flags {
+			if strings.HasPrefix(f, "--attach=") {
+				return fakeShowAttachment(state, positional[0], strings.TrimPrefix(f, "--attach="))
+			}
+		}
+		if !hasFlag("--json") {
+			return fakeShowPlain(state, positional[0])
+		}
+		return fakeShow(state, positional[0], hasFlag("-G"), hasFlag("-x"))
+	case "mv":
+		for i := range state.Secrets {
+			if state.Secrets[i].ID == positional[0] {
+				_, name := splitName(state.Secrets[i].Fullname)
+				state.Secrets[i].Fullname = positional[1] + "/" + name
+				return 0
+			}
+		}
+	case "edit":
+		for i := range state.Secrets {
+			if state.Secrets[i].ID == positional[0] && hasFlag("--name") {
+				name, _ := ioutil.ReadAll(os.Stdin)
+				state.Secrets[i].Fullname = strings.TrimSpace(string(name))
+				return 0
+			}
+			if state.Secrets[i].ID == positional[0] {
+				// the template of a secure note can't be changed by edit
+				noteType := ""
+				if note := state.Secrets[i].Note; strings.HasPrefix(note, "NoteType:") {
+					noteType = strings.TrimPrefix(strings.SplitN(note, "\n", 2)[0], "NoteType:")
+				}
+				s := parseFakeTemplate(os.Stdin, noteType)
+				s.ID = state.Secrets[i].ID
+				state.Secrets[i] = s
+				return 0
+			}
+		}
+	case "rm":
+		for i := range state.Secrets {
+			if state.Secrets[i].ID == positional[0] {
+				state.Secrets = append(state.Secrets[:i], state.Secrets[i+1:]...)
+				return 0
+			}
+		}
+	default:
+		fmt.Fprintf(os.Stderr, "lpass: unknown command %s", args[0])
+		return 1
+	}
+	fmt.Fprint(os.Stderr, "Error: Could not find specified account(s).")
+	return 1
+}
+
+func fakeShow(state *fakeState, query string, regex, expand bool) int {
+	var matches []fakeSecret
+	for _, s := range state.Secrets {
+		if regex {
+			re, err := regexp.Compile(query)
+			if err != nil {
+				fmt.Fprint(os.Stderr, "Error: Invalid regex.")
+				return 1
+			}
+			if re.MatchString(s.ID) || re.MatchString(s.Fullname) {
+				matches = append(matches, s)
+			}
+		} else if s.ID == query || s.Fullname == query || filepath.Base(s.Fullname) == query {
+			matches = append(matches, s)
+		}
+	}
+	if len(matches) > 0 && matches[len(matches)-1].ID == state.PendingID {
+		// the newest secret is still syncing with upstream
+		if state.HiddenShows > 0 {
+			state.HiddenShows--
+			matches = matches[:len(matches)-1]
+		} else if state.ZeroIDShows > 0 {
+			state.ZeroIDShows--
+			matches[len(matches)-1].ID = "0"
+		}
+	}
+	if len(matches) == 0 {
+		fmt.Fprint(os.Stderr, "Error: Could not find specified account(s).")
+		return 1
+	}
+	if len(matches) > 1 && !expand {
+		fmt.Fprint(os.Stderr, "Multiple matches found.")
+		return 1
+	}
+	var out []map[string]interface{}
+	for _, s := range matches {
+		group, name := filepath.Split(s.Fullname)
+		out = append(out, map[string]interface{}{
+			"id":                s.ID,
+			"name":              name,
+			"fullname":          s.Fullname,
+			"username":          s.Username,
+			"password":          s.Password,
+			"last_modified_gmt": "1617281234",
+			"last_touch":        "1617280000",
+			"group":             strings.TrimSuffix(group, "/"),
+			"url":               s.URL,
+			"note":              s.Note,
+		})
+		if s.Share != "" {
+			out[len(out)-1]["share"] = s.Share
+			out[len(out)-1]["share_readonly"] = s.ShareReadonly
+		}
+	}
+	data, _ := json.MarshalIndent(out, "", "  ")
+	fmt.Println(string(data))
+	return 0
+}
+
+// fakeShowPlain prints a secret the way lpass show does without --json.
+func fakeShowPlain(state *fakeState, id string) int {
+	for _, s := range state.Secrets {
+		if s.ID != id {
+			continue
+		}
+		fmt.Printf("%s [id: %s]\nUsername: %s\nPassword: %s\n", s.Fullname, s.ID, s.Username, s.Password)
+		var ids []string
+		for id := range s.Attachments {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		for _, id := range ids {
+			fmt.Printf("%s: %s\n", id, s.Attachments[id].Filename)
+		}
+		if s.Note != "" {
+			fmt.Printf("Notes: %s\n", s.Note)
+		}
+		return 0
+	}
+	fmt.Fprint(os.Stderr, "Error: Could not find specified account(s).")
+	return 1
+}
+
+// fakeShowAttachment writes an attachment to stdout like lpass show --attach.
+func fakeShowAttachment(state *fakeState, id, attachmentID string) int {
+	for _, s := range state.Secrets {
+		if a, ok := s.Attachments[attachmentID]; ok && s.ID == id {
+			os.Stdout.Write(a.Content)
+			return 0
+		}
+	}
+	fmt.Fprint(os.Stderr, "Error: Could not find specified attachment.")
+	return 1
+}
+
+// fakeNoteType returns the NoteType of a --note-type flag.
+func fakeNoteType(flags []string) string {
+	for _, f := range flags {
+		if strings.HasPrefix(f, "--note-type=") {
+			for name, arg := range NoteTypes {
+				if arg == strings.TrimPrefix(f, "--note-type=") {
+					return name
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// fakeLs lists all secrets, it supports the --format codes used by List.
+func fakeLs(state *fakeState, flags []string) int {
+	format := "%ai %aN"
+	for _, f := range flags {
+		if strings.HasPrefix(f, "--format=") {
+			format = strings.TrimPrefix(f, "--format=")
+		}
+	}
+	for _, s := range state.Secrets {
+		group, _ := filepath.Split(s.Fullname)
+		id := s.ID
+		if id == state.PendingID && (state.HiddenShows > 0 || state.ZeroIDShows > 0) {
+			id = "0"
+		}
+		r := strings.NewReplacer(
+			"%ai", id,
+			"%aN", s.Fullname,
+			"%ag", strings.TrimSuffix(group, "/"),
+			"%al", s.URL,
+			"%au", s.Username,
+			"%as", s.Share,
+		)
+		fmt.Println(r.Replace(format))
+	}
+	return 0
+}
+
+// fakeShare implements the lpass share subcommands.
+func fakeShare(state *fakeState, args, flags []string) int {
+	if state.Shares == nil {
+		state.Shares = make(map[string][]ShareUser)
+	}
+	members, ok := state.Shares[args[1]]
+	if args[0] == "create" {
+		if ok {
+			fmt.Fprintf(os.Stderr, "Error: Shared folder %s already exists.", args[1])
+			return 1
+		}
+		state.Shares[args[1]] = []ShareUser{{Username: state.Username, Admin: true, Accepted: true}}
+		return 0
+	}
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Error: Unable to find shared folder %s.", args[1])
+		return 1
+	}
+	user := ShareUser{}
+	if len(args) > 2 {
+		user.Username = args[2]
+	}
+	for _, f := range flags {
+		switch f {
+		case "--read-only=true":
+			user.ReadOnly = true
+		case "--hidden=true":
+			user.HidePasswords = true
+		case "--admin=true":
+			user.Admin = true
+		}
+	}
+	index := -1
+	for i, m := range members {
+		if strings.EqualFold(m.Username, user.Username) {
+			index = i
+		}
+	}
+	switch args[0] {
+	case "rm":
+		delete(state.Shares, args[1])
+	case "userls":
+		fmt.Printf("%-40s %6s %6s %6s %6s %6s\n", "User", "RO", "Admin", "Hide", "OutEnt", "Accept")
+		mark := func(v bool) string {
+			if v {
+				return "x"
+			}
+			return "_"
+		}
+		for _, m := range members {
+			name := strings.Split(m.Username, "@")[0] + " <" + m.Username + ">"
+			fmt.Printf("%-40s %6s %6s %6s %6s %6s\n", name, mark(m.ReadOnly), mark(m.Admin), mark(m.HidePasswords), "_", mark(m.Accepted))
+		}
+	case "useradd":
+		if index >= 0 {
+			fmt.Fprint(os.Stderr, "Error: User is already a member.")
+			return 1
+		}
+		state.Shares[args[1]] = append(members, user)
+	case "usermod", "userdel":
+		if index < 0 {
+			fmt.Fprintf(os.Stderr, "Error: Unable to find user %s in the list.", user.Username)
+			return 1
+		}
+		if args[0] == "userdel" {
+			state.Shares[args[1]] = append(members[:index], members[index+1:]...)
+		} else {
+			user.Accepted = members[index].Accepted
+			members[index] = user
+		}
+	}
+	return 0
+}
+
+// parseFakeTemplate reads the template lpass edit --non-interactive expects.
+// Fields of secure notes end up in the note, the way Lastpass stores them.
+func parseFakeTemplate(f *os.File, noteType string) fakeSecret {
+	data, _ := ioutil.ReadAll(f)
+	var s fakeSecret
+	var names, values []string
+	lines := strings.Split(string(data), "\n")
+	for i, line := range lines {
+		if i == 0 && strings.HasPrefix(line, "Name: ") {
+			s.Fullname = strings.TrimPrefix(line, "Name: ")
+			continue
+		}
+		if strings.HasPrefix(line, "Notes:") {
+			// lastpass trims trailing new lines from notes
+			s.Note = strings.TrimRight(strings.Join(lines[i+1:], "\n"), "\n")
+			break
+		}
+		name := strings.SplitN(line, ":", 2)[0]
+		known, ok := fakeTemplateFields[noteType]
+		if strings.Contains(line, ":") && (!ok || stringInSlice(name, known)) {
+			names = append(names, name)
+			values = append(values, strings.TrimPrefix(strings.TrimPrefix(line, name+":"), " "))
+		} else if len(values) > 0 {
+			values[len(values)-1] += "\n" + line
+		}
+	}
+	if noteType != "" {
+		// secure notes keep their fields in the note text
+		note := "NoteType:" + noteType + "\nLanguage:en-US\n"
+		for i := range names {
+			note += names[i] + ":" + values[i] + "\n"
+		}
+		s.URL = "http://sn"
+		s.Note = note + "Notes:" + s.Note
+		return s
+	}
+	for i, name := range names {
+		switch name {
+		case "URL":
+			s.URL = values[i]
+		case "Username":
+			s.Username = values[i]
+		case "Password":
+			s.Password = values[i]
+		}
+	}
+	return s
+}
+
+// fakeTemplateFields are the fields lpass knows of templates with values
+// spanning several lines, other lines continue the field above.
+var fakeTemplateFields = map[string][]string{
+	"SSH Key": {"Username", "Password", "Bit Strength", "Format", "Passphrase", "Private Key", "Public Key", "Hostname", "Date"},
+}
@@ -0,0 +1,150 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// folderURL is the URL Lastpass uses for the entries representing folders.
+// A folder entry has an empty name, its group is the path of the folder.
+const folderURL = "http://group"
+
+// IsFolder reports whether s is a folder rather than a secret.
+func (s *Secret) IsFolder() bool {
+	return s.URL == folderURL
+}
+
+// normalizeFolder makes a folder entry look like a secret named after the
+// folder, inside its parent folder.
+func normalizeFolder(s Secret) Secret {
+	if !s.IsFolder() {
+		return s
+	}
+	s.Fullname = strings.TrimSuffix(s.Fullname, "/")
+	s.Name = s.Fullname
+	s.Group = path.Dir(s.Fullname)
+	if s.Group == "." {
+		s.Group = ""
+	}
+	return s
+}
+
+// splitName splits a full name into its folder and name.
+func splitName(fullname string) (group, name string) {
+	if i := strings.LastIndex(fullname, "/"); i >= 0 {
+		return fullname[:i], fullname[i+1:]
+	}
+	return "", fullname
+}
+
+// inFolder reports whether s is inside folder, or any of its subfolders.
+func inFolder(s Secret, folder string) bool {
+	return s.Group == folder || strings.HasPrefix(s.Group, folder+"/")
+}
+
+// CreateFolder creates the folder at the given path, e.g. "Infra/Databases".
+func (c *Client) CreateFolder(name string) (Secret, error) {
+	return c.CreateFolderContext(context.Background(), name)
+}
+
+// CreateFolderContext is like CreateFolder, but gives up when ctx is done.
+func (c *Client) CreateFolderContext(ctx context.Context, name string) (Secret, error) {
+	name = strings.Trim(name, "/")
+	s := Secret{Name: name + "/", URL: folderURL}
+	err := c.login(ctx)
+	if err != nil {
+		return s, err
+	}
+	err = c.writeNow(ctx, "create folder "+name, func(ctx context.Context) error {
+		s, err = c.backend().Create(ctx, s)
+		return err
+	})
+	c.evictCreated(s.ID, err)
+	return normalizeFolder(s), err
+}
+
+// RenameFolder moves the folder with the given ID and everything inside it.
+func (c *Client) RenameFolder(id, name string) error {
+	return c.RenameFolderContext(context.Background(), id, name)
+}
+
+// RenameFolderContext is like RenameFolder, but gives up when ctx is done.
+func (c *Client) RenameFolderContext(ctx context.Context, id, name string) error {
+	name = strings.Trim(name, "/")
+	folder, all, err := c.folder(ctx, id)
+	if err != nil {
+		return err
+	}
+	if folder.Fullname == name {
+		return nil
+	}
+	for _, s := range all {
+		if s.ID == id || !inFolder(s, folder.Fullname) {
+			continue
+		}
+		fullname := name + strings.TrimPrefix(s.Fullname, folder.Fullname)
+		if s.IsFolder() {
+			fullname += "/"
+		}
+		err = c.write(ctx, "rename "+s.ID, func(ctx context.Context) error {
+			return c.backend().Rename(ctx, s.ID, fullname)
+		})
+		c.evict(s.ID)
+		if err != nil {
+			return err
+		}
+	}
+	defer c.evict(id)
+	return c.write(ctx, "rename folder "+id, func(ctx context.Context) error {
+		return c.backend().Rename(ctx, id, name+"/")
+	})
+}
+
+// DeleteFolder removes the folder with the given ID, it has to be empty.
+func (c *Client) DeleteFolder(id string) error {
+	return c.DeleteFolderContext(context.Background(), id)
+}
+
+// DeleteFolderContext is like DeleteFolder, but gives up when ctx is done.
+func (c *Client) DeleteFolderContext(ctx context.Context, id string) error {
+	folder, all, err := c.folder(ctx, id)
+	if err != nil {
+		return err
+	}
+	for _, s := range all {
+		if s.ID != id && inFolder(s, folder.Fullname) {
+			return &Error{Err: ErrNotEmpty, Message: fmt.Sprintf("%s contains %s", folder.Fullname, s.Fullname)}
+		}
+	}
+	defer c.evict(id)
+	return c.write(ctx, "delete folder "+id, func(ctx context.Context) error {
+		return c.backend().Delete(ctx, id)
+	})
+}
+
+// folder returns the folder with the given ID together with everything in
+// the vault.
+func (c *Client) folder(ctx context.Context, id string) (Secret, []Secret, error) {
+	err := c.login(ctx)
+	if err != nil {
+		return Secret{}, nil, err
+	}
+	secrets, err := c.readID(ctx, id)
+	if err != nil {
+		return Secret{}, nil, err
+	}
+	folder := normalizeFolder(secrets[0])
+	if !folder.IsFolder() {
+		return folder, nil, fmt.Errorf("%s (%s) is not a folder", folder.Fullname, id)
+	}
+	all, err := c.cachedList(ctx)
+	if err != nil {
+		return folder, nil, err
+	}
+	for i := range all {
+		all[i] = normalizeFolder(all[i])
+	}
+	return folder, all, nil
+}
@@ -0,0 +1,86 @@
+package api
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestClientFolders(t *testing.T) {
+	f, b := newFakeLpass(t, fakeState{LoggedIn: true, LastID: 10, Secrets: []fakeSecret{
+		{ID: "1", Fullname: "Other/db"},
+	}})
+	client := Client{Backend: b}
+	folder, err := client.CreateFolder("Infra/Databases")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if folder.ID != "11" || folder.Fullname != "Infra/Databases" || folder.Group != "Infra" || !folder.IsFolder() {
+		t.Errorf("CreateFolder() returned unexpected folder: %+v", folder)
+	}
+	sub, err := client.CreateFolder("Infra/Databases/Prod")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Infra/Databases/db1", "Infra/Databases/Prod/db2", "Infra/Databases-old/db3"} {
+		if _, err := client.Create(Secret{Name: name}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	err = client.DeleteFolder(sub.ID)
+	if !errors.Is(err, ErrNotEmpty) || !strings.Contains(err.Error(), "Infra/Databases/Prod/db2") {
+		t.Errorf("expected folder not empty error, got %v", err)
+	}
+	err = client.DeleteFolder("1")
+	if err == nil || !strings.Contains(err.Error(), "not a folder") {
+		t.Errorf("expected not a folder error, got %v", err)
+	}
+	err = client.RenameFolder(folder.ID, "Apps/DBs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, s := range f.state().Secrets {
+		names = append(names, s.Fullname)
+	}
+	sort.Strings(names)
+	want := "Apps/DBs/ Apps/DBs/Prod/ Apps/DBs/Prod/db2 Apps/DBs/db1 Infra/Databases-old/db3 Other/db"
+	if got := strings.Join(names, " "); got != want {
+		t.Errorf("expected %q after rename, got %q", want, got)
+	}
+	secrets, err := client.Read(folder.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if secrets[0].Fullname != "Apps/DBs" || secrets[0].Group != "Apps" {
+		t.Errorf("folder not renamed: %+v", secrets[0])
+	}
+	listed, _ := client.List(ListFilter{Folder: "Apps/DBs"})
+	if len(listed) != 2 {
+		t.Errorf("expected the 2 secrets in the renamed folder, got %+v", listed)
+	}
+}
+
+func TestMemoryBackendFolders(t *testing.T) {
+	client := Client{Backend: &MemoryBackend{}}
+	folder, err := client.CreateFolder("Infra")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, _ := client.Create(Secret{Name: "Infra/db"})
+	err = client.RenameFolder(folder.ID, "Infra2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	secrets, _ := client.Read(s.ID)
+	if secrets[0].Fullname != "Infra2/db" {
+		t.Errorf("secret not moved with its folder: %+v", secrets[0])
+	}
+	if err := client.Delete(s.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.DeleteFolder(folder.ID); err != nil {
+		t.Errorf("deleting an empty folder failed: %v", err)
+	}
+}
@@ -0,0 +1,90 @@
+package api
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"strings"
+)
+
+// Character classes used by GeneratePassword.
+const (
+	lowerChars   = "abcdefghijklmnopqrstuvwxyz"
+	upperChars   = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	numericChars = "0123456789"
+	specialChars = "!@#$%^&*()-_=+[]{}<>:?"
+)
+
+// PasswordPolicy describes a generated password.
+type PasswordPolicy struct {
+	Length  int
+	Lower   bool
+	Upper   bool
+	Numeric bool
+	Special bool
+	// Exclude lists characters never used, e.g. ones a service rejects.
+	Exclude string
+}
+
+// GeneratePassword returns a random password using crypto/rand, with at
+// least one character of every class in the policy.
+func GeneratePassword(p PasswordPolicy) (string, error) {
+	var classes []string
+	for _, c := range []struct {
+		chars   string
+		enabled bool
+	}{{lowerChars, p.Lower}, {upperChars, p.Upper}, {numericChars, p.Numeric}, {specialChars, p.Special}} {
+		if !c.enabled {
+			continue
+		}
+		chars := strings.Map(func(r rune) rune {
+			if strings.ContainsRune(p.Exclude, r) {
+				return -1
+			}
+			return r
+		}, c.chars)
+		if chars == "" {
+			return "", errors.New("all characters of a class are excluded")
+		}
+		classes = append(classes, chars)
+	}
+	if len(classes) == 0 {
+		return "", errors.New("no character classes enabled")
+	}
+	if p.Length < len(classes) {
+		return "", errors.New("password too short to contain every character class")
+	}
+	all := strings.Join(classes, "")
+	password := make([]byte, 0, p.Length)
+	for _, chars := range classes {
+		c, err := randChar(chars)
+		if err != nil {
+			return "", err
+		}
+		password = append(password, c)
+	}
+	for len(password) < p.Length {
+		c, err := randChar(all)
+		if err != nil {
+			return "", err
+		}
+		password = append(password, c)
+	}
+	// don't leave the guaranteed characters at the start
+	for i := len(password) - 1; i > 0; i-- {
+		j, err := rand.Int(rand.Reader, big.NewInt(int64(i+1)))
+		if err != nil {
+			return "", err
+		}
+		password[i], password[j.Int64()] = password[j.Int64()], password[i]
+	}
+	return string(password), nil
+}
+
+func randChar(chars string) (byte, error) {
+	i, err := rand.Int(rand.Reader, big.NewInt(int64(len(chars))))
+	if err != nil {
+		return 0, err
+	}
+	return chars[i.Int64()], nil
+}
@@ -0,0 +1,45 @@
+package api
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGeneratePassword(t *testing.T) {
+	p := PasswordPolicy{Length: 24, Lower: true, Upper: true, Numeric: true, Special: true, Exclude: "0O1lI"}
+	seen := make(map[string]bool)
+	for i := 0; i < 50; i++ {
+		pw, err := GeneratePassword(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(pw) != 24 {
+			t.Fatalf("expected 24 characters, got %q", pw)
+		}
+		if strings.ContainsAny(pw, p.Exclude) {
+			t.Errorf("excluded character in %q", pw)
+		}
+		for _, class := range []string{lowerChars, upperChars, numericChars, specialChars} {
+			if !strings.ContainsAny(pw, class) {
+				t.Errorf("%q is missing a character of %q", pw, class)
+			}
+		}
+		if seen[pw] {
+			t.Errorf("password %q generated twice", pw)
+		}
+		seen[pw] = true
+	}
+	pw, _ := GeneratePassword(PasswordPolicy{Length: 8, Numeric: true})
+	if strings.Trim(pw, numericChars) != "" {
+		t.Errorf("expected only digits, got %q", pw)
+	}
+	for _, p := range []PasswordPolicy{
+		{Length: 8},
+		{Length: 1, Lower: true, Upper: true},
+		{Length: 8, Numeric: true, Exclude: numericChars},
+	} {
+		if _, err := GeneratePassword(p); err == nil {
+			t.Errorf("expected error for %+v", p)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package api
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// ParseGmt parses the Unix timestamps Lastpass uses, e.g. last_modified_gmt.
+// An empty string gives the zero time.
+func ParseGmt(v string) (time.Time, error) {
+	if v == "" {
+		return time.Time{}, nil
+	}
+	sec, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid timestamp %q", v)
+	}
+	return time.Unix(sec, 0).UTC(), nil
+}
+
+// LastModified returns when the secret was last changed.
+func (s *Secret) LastModified() (time.Time, error) {
+	return ParseGmt(s.LastModifiedGmt)
+}
+
+// LastTouched returns when the secret was last used.
+func (s *Secret) LastTouched() (time.Time, error) {
+	return ParseGmt(s.LastTouch)
+}
+
+// PasswordChanged returns when the password last changed, the zero time when
+// the backend doesn't know.
+func (s *Secret) PasswordChanged() (time.Time, error) {
+	return ParseGmt(s.LastPasswordChangeGmt)
+}
@@ -0,0 +1,87 @@
+package api
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestParseGmt(t *testing.T) {
+	got, err := ParseGmt("1617281234")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2021, 4, 1, 12, 47, 14, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	got, err = ParseGmt("")
+	if err != nil || !got.IsZero() {
+		t.Errorf("empty timestamp: got %v, %v", got, err)
+	}
+	_, err = ParseGmt("yesterday")
+	if err == nil {
+		t.Error("expected an error for an invalid timestamp")
+	}
+}
+
+func TestCLIBackendPasswordChanges(t *testing.T) {
+	native, srv := newFakeLastpass(t)
+	native.accounts = []Secret{{ID: "999", Name: "db", Password: "hunter2"}}
+	f, b := newFakeLpass(t, fakeState{LoggedIn: true, Username: native.username, Secrets: []fakeSecret{
+		{ID: "999", Fullname: "db", Password: "hunter2"},
+		{ID: "1000", Fullname: "web"},
+	}})
+	client := Client{Username: native.username, Password: native.password, Backend: b}
+	secrets, err := client.Read("999")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if secrets[0].LastPasswordChangeGmt != "" {
+		t.Errorf("lpass can't know when the password changed, got %q", secrets[0].LastPasswordChangeGmt)
+	}
+	b.PasswordChanges = &NativeBackend{BaseURL: srv.URL}
+	client = Client{Username: native.username, Password: native.password, Backend: b}
+	secrets, err = client.Read("999")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if secrets[0].LastPasswordChangeGmt != "1617280500" || secrets[0].Password != "hunter2" {
+		t.Errorf("expected the password change time from the Lastpass API, got %+v", secrets[0])
+	}
+	secrets, err = client.Read("1000")
+	if err != nil || secrets[0].LastPasswordChangeGmt != "" {
+		t.Errorf("expected no password change time for a secret the API doesn't know, got %+v, %v", secrets, err)
+	}
+	if shows := countShows(f); shows != 2 {
+		t.Errorf("expected the vault to be loaded once per client, got %d show calls", shows)
+	}
+}
+
+func TestMemoryBackendPasswordChanged(t *testing.T) {
+	ctx := context.Background()
+	b := &MemoryBackend{}
+	s, err := b.Create(ctx, Secret{Name: "db", Password: "hunter2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.LastPasswordChangeGmt = "1"
+	b.secrets[s.ID] = s
+	s.Note = "only the note"
+	err = b.Update(ctx, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secrets, _ := b.Read(ctx, s.ID)
+	if secrets[0].LastPasswordChangeGmt != "1" {
+		t.Errorf("note change moved the password change time to %s", secrets[0].LastPasswordChangeGmt)
+	}
+	s.Password = "rotated"
+	err = b.Update(ctx, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secrets, _ = b.Read(ctx, s.ID)
+	if secrets[0].LastPasswordChangeGmt == "1" {
+		t.Error("password change time not updated")
+	}
+}
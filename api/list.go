@@ -0,0 +1,194 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// listFields are the lpass ls --format codes we read, in order.
+var listFields = []string{"%ai", "%aN", "%ag", "%al", "%au", "%as"}
+
+// listSeparator splits the fields of a line of lpass ls output.
+const listSeparator = "\x1f"
+
+// List returns all secrets and folders with lpass ls, without passwords or
+// notes.
+func (b *CLIBackend) List(ctx context.Context) ([]Secret, error) {
+	var secrets []Secret
+	format := strings.Join(listFields, listSeparator)
+	cmd := b.command(ctx, "ls", "--sync=auto", "--color=never", "--format="+format)
+	var outbuf, errbuf bytes.Buffer
+	cmd.Stdout = &outbuf
+	cmd.Stderr = &errbuf
+	err := cmd.Run()
+	if err != nil {
+		return secrets, commandError(ctx, errbuf.String())
+	}
+	for _, line := range strings.Split(outbuf.String(), "\n") {
+		f := strings.Split(line, listSeparator)
+		if len(f) != len(listFields) {
+			continue
+		}
+		s := Secret{
+			ID:       f[0],
+			Fullname: f[1],
+			Group:    f[2],
+			URL:      f[3],
+			Username: f[4],
+			Share:    f[5],
+		}
+		if s.ID == "0" {
+			// not synced yet
+			continue
+		}
+		s.Name = s.Fullname
+		secrets = append(secrets, s)
+	}
+	return secrets, nil
+}
+
+// ListFilter picks the secrets returned by List, empty fields match anything.
+type ListFilter struct {
+	// Folder matches secrets in the folder or any of its subfolders.
+	Folder string
+	// Name is a regular expression matched against the full name.
+	Name string
+	// Share is the name of the shared folder.
+	Share string
+	// NoteType is the secure note template, see NoteTypes.
+	NoteType string
+	// ModifiedAfter and ModifiedBefore limit the last modification time.
+	ModifiedAfter  time.Time
+	ModifiedBefore time.Time
+}
+
+// needsDetails reports whether the filter uses fields lpass ls can't give us.
+func (f ListFilter) needsDetails() bool {
+	return f.NoteType != "" || !f.ModifiedAfter.IsZero() || !f.ModifiedBefore.IsZero()
+}
+
+// match checks the fields every backend lists.
+func (f ListFilter) match(s Secret) (bool, error) {
+	folder := strings.Trim(f.Folder, "/")
+	if folder != "" && s.Group != folder && !strings.HasPrefix(s.Group, folder+"/") {
+		return false, nil
+	}
+	if f.Share != "" && s.Share != f.Share {
+		return false, nil
+	}
+	if f.Name != "" {
+		re, err := regexp.Compile(f.Name)
+		if err != nil {
+			return false, err
+		}
+		if !re.MatchString(s.Fullname) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// matchDetails checks the note type and modification time.
+func (f ListFilter) matchDetails(s Secret) (bool, error) {
+	if f.NoteType != "" && s.NoteType != f.NoteType {
+		return false, nil
+	}
+	if f.ModifiedAfter.IsZero() && f.ModifiedBefore.IsZero() {
+		return true, nil
+	}
+	modified, err := s.LastModified()
+	if err != nil || modified.IsZero() {
+		return false, fmt.Errorf("invalid last_modified_gmt %q of secret %s", s.LastModifiedGmt, s.ID)
+	}
+	if !f.ModifiedAfter.IsZero() && !modified.After(f.ModifiedAfter) {
+		return false, nil
+	}
+	if !f.ModifiedBefore.IsZero() && !modified.Before(f.ModifiedBefore) {
+		return false, nil
+	}
+	return true, nil
+}
+
+// Selector picks a single secret by its attributes, empty fields match
+// anything. With Regex set the fields are regular expressions, otherwise
+// they have to match exactly.
+type Selector struct {
+	// Name is the name of the secret, without its folder.
+	Name string
+	// Fullname is the name including the folder path.
+	Fullname string
+	URL      string
+	Username string
+	Regex    bool
+}
+
+func (sel Selector) String() string {
+	var parts []string
+	for _, f := range [][2]string{{"name", sel.Name}, {"fullname", sel.Fullname}, {"url", sel.URL}, {"username", sel.Username}} {
+		if f[1] != "" {
+			parts = append(parts, fmt.Sprintf("%s=%q", f[0], f[1]))
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// BaseName returns the name of the secret without its folder.
+func (s *Secret) BaseName() string {
+	if s.Group == "" {
+		return s.Fullname
+	}
+	return strings.TrimPrefix(s.Fullname, s.Group+"/")
+}
+
+// match reports whether s is picked by the selector.
+func (sel Selector) match(s Secret) (bool, error) {
+	name := s.BaseName()
+	for _, f := range [][2]string{{sel.Name, name}, {sel.Fullname, s.Fullname}, {sel.URL, s.URL}, {sel.Username, s.Username}} {
+		if f[0] == "" {
+			continue
+		}
+		if !sel.Regex {
+			if f[0] != f[1] {
+				return false, nil
+			}
+			continue
+		}
+		re, err := regexp.Compile(f[0])
+		if err != nil {
+			return false, err
+		}
+		if !re.MatchString(f[1]) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// selectSecret returns the only secret picked by sel.
+func selectSecret(secrets []Secret, sel Selector) (Secret, error) {
+	var matches []Secret
+	for _, s := range secrets {
+		ok, err := sel.match(s)
+		if err != nil {
+			return Secret{}, err
+		}
+		if ok {
+			matches = append(matches, s)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return Secret{}, &Error{Err: ErrNotFound, Message: "no secret matches " + sel.String()}
+	case 1:
+		return matches[0], nil
+	}
+	var ids []string
+	for _, s := range matches {
+		ids = append(ids, s.ID+" ("+s.Fullname+")")
+	}
+	return Secret{}, &Error{Err: ErrAmbiguousName, Message: fmt.Sprintf("%d secrets match %s: %s", len(matches), sel, strings.Join(ids, ", "))}
+}
@@ -0,0 +1,132 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCLIBackendList(t *testing.T) {
+	ctx := context.Background()
+	_, b := newFakeLpass(t, fakeState{LoggedIn: true, PendingID: "4", ZeroIDShows: 1, Secrets: []fakeSecret{
+		{ID: "1", Fullname: "Infra/db", Username: "admin", URL: "https://db.example.com"},
+		{ID: "2", Fullname: "Infra/", URL: "http://group"},
+		{ID: "3", Fullname: "Shared-Team/Infra/db", Username: "admin", Share: "Shared-Team"},
+		{ID: "4", Fullname: "syncing"},
+	}})
+	secrets, err := b.List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(secrets) != 3 || !secrets[1].IsFolder() {
+		t.Fatalf("expected unsynced secrets to be left out, got %+v", secrets)
+	}
+	secrets, err = (&Client{Backend: b}).List(ListFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(secrets) != 2 {
+		t.Fatalf("expected folders to be left out, got %+v", secrets)
+	}
+	s := secrets[1]
+	if s.ID != "3" || s.Name != "Shared-Team/Infra/db" || s.Group != "Shared-Team/Infra" || s.Share != "Shared-Team" || s.Username != "admin" {
+		t.Errorf("unexpected secret listed: %+v", s)
+	}
+}
+
+func TestClientFind(t *testing.T) {
+	_, b := newFakeLpass(t, fakeState{LoggedIn: true, Secrets: []fakeSecret{
+		{ID: "1", Fullname: "Infra/db", Username: "admin", Password: "pw", URL: "https://db.example.com"},
+		{ID: "2", Fullname: "Apps/db", Username: "app", URL: "https://db.example.com"},
+		{ID: "3", Fullname: "Apps/web", Username: "app", URL: "https://www.example.com"},
+	}})
+	client := Client{Backend: b}
+	tests := []struct {
+		sel Selector
+		id  string
+		err error
+	}{
+		{Selector{Fullname: "Infra/db"}, "1", nil},
+		{Selector{Name: "db", Username: "app"}, "2", nil},
+		{Selector{URL: "https://www.example.com"}, "3", nil},
+		{Selector{Name: "db"}, "", ErrAmbiguousName},
+		{Selector{Name: "Infra/db"}, "", ErrNotFound},
+		{Selector{Fullname: "^Apps/", URL: `\.www\.`, Regex: true}, "", ErrNotFound},
+		{Selector{Fullname: "^Apps/", URL: `//www\.`, Regex: true}, "3", nil},
+		{Selector{Name: "^d", Regex: true}, "", ErrAmbiguousName},
+	}
+	for _, tt := range tests {
+		s, err := client.Find(tt.sel)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("Find(%s): expected error %v, got %v", tt.sel, tt.err, err)
+			continue
+		}
+		if s.ID != tt.id {
+			t.Errorf("Find(%s): expected ID %q, got %q", tt.sel, tt.id, s.ID)
+		}
+	}
+	s, _ := client.Find(Selector{Fullname: "Infra/db"})
+	if s.Password != "pw" {
+		t.Error("Find() should read the whole secret")
+	}
+	_, err := client.Find(Selector{Name: "db"})
+	if err == nil || !strings.Contains(err.Error(), "1 (Infra/db), 2 (Apps/db)") {
+		t.Errorf("expected matching secrets listed in error, got %v", err)
+	}
+	_, err = client.Find(Selector{Name: "(", Regex: true})
+	if err == nil || errors.Is(err, ErrNotFound) {
+		t.Errorf("expected invalid regex error, got %v", err)
+	}
+}
+
+func TestClientList(t *testing.T) {
+	f, b := newFakeLpass(t, fakeState{LoggedIn: true, Secrets: []fakeSecret{
+		{ID: "1", Fullname: "Infra/db", Username: "admin"},
+		{ID: "2", Fullname: "Infra/Servers/web", URL: "http://sn", Note: "NoteType:Server\nLanguage:en-US\nHostname:web\nNotes:"},
+		{ID: "3", Fullname: "Infrastructure/dns"},
+		{ID: "4", Fullname: "Shared-Team/Infra/db", Share: "Shared-Team"},
+	}})
+	client := Client{Backend: b}
+	tests := []struct {
+		filter ListFilter
+		ids    string
+	}{
+		{ListFilter{}, "1 2 3 4"},
+		{ListFilter{Folder: "Infra"}, "1 2"},
+		{ListFilter{Folder: "Infra/Servers/"}, "2"},
+		{ListFilter{Name: "db$"}, "1 4"},
+		{ListFilter{Share: "Shared-Team"}, "4"},
+		{ListFilter{Folder: "Infra", NoteType: "Server"}, "2"},
+		{ListFilter{ModifiedAfter: time.Unix(1617281000, 0), ModifiedBefore: time.Unix(1617282000, 0)}, "1 2 3 4"},
+		{ListFilter{ModifiedAfter: time.Unix(1617282000, 0)}, ""},
+	}
+	for _, tt := range tests {
+		secrets, err := client.List(tt.filter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var ids []string
+		for _, s := range secrets {
+			ids = append(ids, s.ID)
+		}
+		if got := strings.Join(ids, " "); got != tt.ids {
+			t.Errorf("List(%+v): expected %q, got %q", tt.filter, tt.ids, got)
+		}
+	}
+	// the vault is loaded once and then served from the cache
+	var shows int
+	for _, call := range f.state().Calls {
+		if strings.HasPrefix(call, "show") {
+			shows++
+		}
+	}
+	if shows != 1 {
+		t.Errorf("expected 1 show call, got %d", shows)
+	}
+	_, err := client.List(ListFilter{Name: "("})
+	if err == nil {
+		t.Error("expected invalid regex error")
+	}
+}
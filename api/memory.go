@@ -0,0 +1,258 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemoryBackend keeps secrets in memory, it is mainly useful for testing.
+type MemoryBackend struct {
+	mu      sync.Mutex
+	secrets map[string]Secret
+	lastID  int
+	// shares maps shared folders to their members by lowercase username.
+	shares map[string]map[string]ShareUser
+	// attachments maps secret IDs to their attachments.
+	attachments  map[string][]Attachment
+	lastAttachID int
+}
+
+// Login always succeeds for the in-memory backend, unless ctx is done.
+func (b *MemoryBackend) Login(ctx context.Context, creds Credentials) error {
+	return ctx.Err()
+}
+
+// Create stores a new secret and assigns it a numeric ID.
+func (b *MemoryBackend) Create(ctx context.Context, s Secret) (Secret, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.secrets == nil {
+		b.secrets = make(map[string]Secret)
+	}
+	s, err := secureNote(s)
+	if err != nil {
+		return s, err
+	}
+	b.lastID++
+	s.ID = strconv.Itoa(b.lastID)
+	s = setNames(s)
+	s.LastModifiedGmt = strconv.FormatInt(time.Now().Unix(), 10)
+	s.LastPasswordChangeGmt = s.LastModifiedGmt
+	b.secrets[s.ID] = s
+	return s, nil
+}
+
+// Read returns the secret with the given ID.
+func (b *MemoryBackend) Read(ctx context.Context, id string) ([]Secret, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var secrets []Secret
+	s, ok := b.secrets[id]
+	if !ok {
+		return secrets, &Error{Err: ErrNotFound, Message: id}
+	}
+	return append(secrets, s), nil
+}
+
+// List returns all secrets ordered by ID.
+func (b *MemoryBackend) List(ctx context.Context) ([]Secret, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var secrets []Secret
+	for i := 1; i <= b.lastID; i++ {
+		if s, ok := b.secrets[strconv.Itoa(i)]; ok {
+			secrets = append(secrets, s)
+		}
+	}
+	return secrets, nil
+}
+
+// Update replaces an existing secret.
+func (b *MemoryBackend) Update(ctx context.Context, s Secret) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	old, ok := b.secrets[s.ID]
+	if !ok {
+		return &Error{Err: ErrNotFound, Message: s.ID}
+	}
+	s, err := secureNote(s)
+	if err != nil {
+		return err
+	}
+	s = setNames(s)
+	s.LastModifiedGmt = strconv.FormatInt(time.Now().Unix(), 10)
+	s.LastPasswordChangeGmt = old.LastPasswordChangeGmt
+	if s.Password != old.Password {
+		s.LastPasswordChangeGmt = s.LastModifiedGmt
+	}
+	b.secrets[s.ID] = s
+	return nil
+}
+
+// Rename changes the full name of a secret.
+func (b *MemoryBackend) Rename(ctx context.Context, id, fullname string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	s, ok := b.secrets[id]
+	if !ok {
+		return &Error{Err: ErrNotFound, Message: id}
+	}
+	s.Name = fullname
+	b.secrets[id] = setNames(s)
+	return nil
+}
+
+// Delete removes a secret.
+func (b *MemoryBackend) Delete(ctx context.Context, id string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.secrets[id]; !ok {
+		return &Error{Err: ErrNotFound, Message: id}
+	}
+	delete(b.secrets, id)
+	delete(b.attachments, id)
+	return nil
+}
+
+// setNames fills in Fullname and Group from the full path in Name,
+// the same way lpass reports them on Read.
+func setNames(s Secret) Secret {
+	s.Fullname = s.Name
+	s.Group = path.Dir(s.Name)
+	if s.Group == "." {
+		s.Group = ""
+	}
+	s.Share = shareName(s.Name)
+	return s
+}
+
+// CreateShare adds an empty shared folder.
+func (b *MemoryBackend) CreateShare(ctx context.Context, name string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.shares == nil {
+		b.shares = make(map[string]map[string]ShareUser)
+	}
+	if _, ok := b.shares[name]; ok {
+		return fmt.Errorf("shared folder %s already exists", name)
+	}
+	b.shares[name] = make(map[string]ShareUser)
+	return nil
+}
+
+// DeleteShare removes a shared folder.
+func (b *MemoryBackend) DeleteShare(ctx context.Context, name string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.shares[name]; !ok {
+		return &Error{Err: ErrNotFound, Message: name}
+	}
+	delete(b.shares, name)
+	return nil
+}
+
+// ShareUsers returns the members of a shared folder ordered by username.
+func (b *MemoryBackend) ShareUsers(ctx context.Context, name string) ([]ShareUser, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	members, ok := b.shares[name]
+	if !ok {
+		return nil, &Error{Err: ErrNotFound, Message: name}
+	}
+	var users []ShareUser
+	for _, u := range members {
+		users = append(users, u)
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].Username < users[j].Username })
+	return users, nil
+}
+
+// AddShareUser adds a member to a shared folder.
+func (b *MemoryBackend) AddShareUser(ctx context.Context, name string, u ShareUser) error {
+	return b.setShareUser(name, u, false)
+}
+
+// UpdateShareUser changes a member of a shared folder.
+func (b *MemoryBackend) UpdateShareUser(ctx context.Context, name string, u ShareUser) error {
+	return b.setShareUser(name, u, true)
+}
+
+func (b *MemoryBackend) setShareUser(name string, u ShareUser, exists bool) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	members, ok := b.shares[name]
+	if !ok {
+		return &Error{Err: ErrNotFound, Message: name}
+	}
+	key := strings.ToLower(u.Username)
+	if _, ok := members[key]; ok != exists {
+		if exists {
+			return &Error{Err: ErrNotFound, Message: u.Username}
+		}
+		return fmt.Errorf("%s is already a member of %s", u.Username, name)
+	}
+	members[key] = u
+	return nil
+}
+
+// RemoveShareUser removes a member from a shared folder.
+func (b *MemoryBackend) RemoveShareUser(ctx context.Context, name, username string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	members, ok := b.shares[name]
+	if !ok {
+		return &Error{Err: ErrNotFound, Message: name}
+	}
+	key := strings.ToLower(username)
+	if _, ok := members[key]; !ok {
+		return &Error{Err: ErrNotFound, Message: username}
+	}
+	delete(members, key)
+	return nil
+}
+
+// Attachments returns the attachments of a secret in the order they were added.
+func (b *MemoryBackend) Attachments(ctx context.Context, id string) ([]Attachment, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.secrets[id]; !ok {
+		return nil, &Error{Err: ErrNotFound, Message: id}
+	}
+	return append([]Attachment(nil), b.attachments[id]...), nil
+}
+
+// AddAttachment attaches a file to a secret and assigns it an ID.
+func (b *MemoryBackend) AddAttachment(ctx context.Context, id string, a Attachment) (Attachment, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.secrets[id]; !ok {
+		return a, &Error{Err: ErrNotFound, Message: id}
+	}
+	if b.attachments == nil {
+		b.attachments = make(map[string][]Attachment)
+	}
+	b.lastAttachID++
+	a.ID = "att-" + strconv.Itoa(b.lastAttachID)
+	b.attachments[id] = append(b.attachments[id], a)
+	return a, nil
+}
+
+// DeleteAttachment removes an attachment from a secret.
+func (b *MemoryBackend) DeleteAttachment(ctx context.Context, id, attachmentID string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	attachments := b.attachments[id]
+	for i, a := range attachments {
+		if a.ID == attachmentID {
+			b.attachments[id] = append(attachments[:i:i], attachments[i+1:]...)
+			return nil
+		}
+	}
+	return &Error{Err: ErrNotFound, Message: "attachment " + attachmentID}
+}
@@ -0,0 +1,71 @@
+package api
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestMemoryBackend(t *testing.T) {
+	client := Client{Backend: &MemoryBackend{}}
+	s, err := client.Create(Secret{
+		Name:     "Folder/mysecret",
+		Username: "user",
+		Password: "pw",
+		Note:     "NoteType:Server\nHostname:example.com\nNotes:hello",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.ID == "" {
+		t.Fatal("Create() did not assign an ID")
+	}
+	secrets, err := client.Read(s.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(secrets) != 1 || secrets[0].Group != "Folder" || secrets[0].Fullname != "Folder/mysecret" {
+		t.Fatalf("Read() returned unexpected secrets: %+v", secrets)
+	}
+	if secrets[0].CustomFields["Hostname"] != "example.com" {
+		t.Errorf("custom fields not parsed: %v", secrets[0].CustomFields)
+	}
+	s.Password = "pw2"
+	err = client.Update(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secrets, _ = client.Read(s.ID)
+	if secrets[0].Password != "pw2" {
+		t.Errorf("Update() did not change password, got %q", secrets[0].Password)
+	}
+	err = client.Delete(s.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = client.Read(s.ID)
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected not found error after Delete(), got %v", err)
+	}
+}
+
+func TestMemoryBackendSecureNote(t *testing.T) {
+	client := Client{Backend: &MemoryBackend{}}
+	s, err := client.Create(Secret{
+		Name:         "mykey",
+		NoteType:     "SSH Key",
+		Password:     "passphrase",
+		CustomFields: map[string]string{"Hostname": "example.com"},
+		Note:         "hello",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	secrets, err := client.Read(s.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := secrets[0]
+	if got.NoteType != "SSH Key" || got.CustomFields["Hostname"] != "example.com" || got.CustomFields["Password"] != "passphrase" || got.CustomFields["Notes"] != "hello" {
+		t.Errorf("secure note did not round-trip: %+v", got)
+	}
+}
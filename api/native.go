@@ -0,0 +1,409 @@
+package api
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultBaseURL = "https://lastpass.com"
+
+// maxLoginAttempts bounds the login retries, mostly out-of-band polling.
+const maxLoginAttempts = 30
+
+// DefaultOutOfBandRetry gives the user a couple of minutes to approve a push
+// notification.
+var DefaultOutOfBandRetry = RetryPolicy{
+	InitialDelay: time.Second,
+	MaxDelay:     5 * time.Second,
+	Jitter:       0.2,
+	Deadline:     2 * time.Minute,
+}
+
+// NativeBackend talks to the Lastpass HTTPS API directly, without lpass.
+type NativeBackend struct {
+	// BaseURL of the Lastpass API, defaults to https://lastpass.com.
+	BaseURL string
+	// HTTPClient used for all requests, defaults to http.DefaultClient.
+	HTTPClient *http.Client
+	// OutOfBandRetry controls how login polls Lastpass while waiting for an
+	// out-of-band approval, zero uses DefaultOutOfBandRetry.
+	OutOfBandRetry RetryPolicy
+
+	mu      sync.Mutex
+	session *nativeSession
+	// shared maps the accounts of shared folders in the last downloaded
+	// vault to their share ID.
+	shared map[string]string
+}
+
+type nativeSession struct {
+	username  string
+	sessionID string
+	token     string
+	key       []byte
+}
+
+type loginResponse struct {
+	OK *struct {
+		SessionID string `xml:"sessionid,attr"`
+		Token     string `xml:"token,attr"`
+	} `xml:"ok"`
+	Error *struct {
+		Message    string `xml:"message,attr"`
+		Cause      string `xml:"cause,attr"`
+		Iterations string `xml:"iterations,attr"`
+		RetryID    string `xml:"retryid,attr"`
+	} `xml:"error"`
+}
+
+type showWebsiteResponse struct {
+	Result struct {
+		Action string `xml:"action,attr"`
+		AID    string `xml:"aid,attr"`
+		Msg    string `xml:"msg,attr"`
+	} `xml:"result"`
+}
+
+// Login performs the iterations/login handshake, reusing an existing session.
+func (b *NativeBackend) Login(ctx context.Context, creds Credentials) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.session != nil && b.session.username == creds.Username {
+		return nil
+	}
+	if creds.Username == "" || creds.Password == "" {
+		return &Error{Err: ErrNotLoggedIn, Message: "username and password are required with the native backend"}
+	}
+	body, err := b.post(ctx, nil, "/iterations.php", url.Values{"email": {creds.Username}})
+	if err != nil {
+		return err
+	}
+	iterations, err := strconv.Atoi(strings.TrimSpace(string(body)))
+	if err != nil {
+		return fmt.Errorf("unexpected iterations response: %q", body)
+	}
+	var key []byte
+	params := url.Values{
+		"method":               {"cli"},
+		"xml":                  {"2"},
+		"username":             {creds.Username},
+		"includeprivatekeyenc": {"1"},
+		"outofbandsupported":   {"1"},
+	}
+	if creds.OTP != "" {
+		params.Set("otp", creds.OTP)
+	}
+	oob := b.OutOfBandRetry
+	if oob == (RetryPolicy{}) {
+		oob = DefaultOutOfBandRetry
+	}
+	oob = oob.withDefaults()
+	var oobDeadline time.Time
+	oobPolls := 0
+	for attempt := 0; attempt < maxLoginAttempts; attempt++ {
+		key = makeKey(creds.Username, creds.Password, iterations)
+		params.Set("hash", makeLoginHash(key, creds.Password, iterations))
+		params.Set("iterations", strconv.Itoa(iterations))
+		body, err = b.post(ctx, nil, "/login.php", params)
+		if err != nil {
+			return err
+		}
+		var resp loginResponse
+		err = xml.Unmarshal(body, &resp)
+		if err != nil {
+			return err
+		}
+		if resp.OK != nil {
+			b.session = &nativeSession{
+				username:  creds.Username,
+				sessionID: resp.OK.SessionID,
+				token:     resp.OK.Token,
+				key:       key,
+			}
+			return nil
+		}
+		if resp.Error == nil {
+			return fmt.Errorf("unexpected login response: %q", body)
+		}
+		// Lastpass tells us the iteration count changed, try again.
+		if n, err := strconv.Atoi(resp.Error.Iterations); err == nil && n != iterations {
+			iterations = n
+			continue
+		}
+		// Wait for the push notification to be approved, Lastpass
+		// holds each request for a while before asking us to retry.
+		if resp.Error.Cause == "outofbandrequired" && creds.OutOfBand {
+			params.Set("outofbandrequest", "1")
+			if resp.Error.RetryID != "" {
+				params.Set("outofbandretry", "1")
+				params.Set("outofbandretryid", resp.Error.RetryID)
+			}
+			if oobDeadline.IsZero() {
+				oobDeadline = time.Now().Add(oob.Deadline)
+			}
+			wait := oob.delay(oobPolls)
+			oobPolls++
+			if time.Now().Add(wait).After(oobDeadline) {
+				break
+			}
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(wait):
+			}
+			continue
+		}
+		return loginError(resp.Error.Cause, resp.Error.Message)
+	}
+	return &Error{Err: ErrMFARequired, Message: "login timed out, out-of-band request was not approved"}
+}
+
+// Create pushes a new account to Lastpass, the ID is returned right away.
+func (b *NativeBackend) Create(ctx context.Context, s Secret) (Secret, error) {
+	aid, err := b.save(ctx, "0", s)
+	if err != nil {
+		return s, err
+	}
+	secrets, err := b.Read(ctx, aid)
+	if err != nil {
+		return s, err
+	}
+	return secrets[0], nil
+}
+
+// Read downloads the vault and returns the account with the given ID.
+func (b *NativeBackend) Read(ctx context.Context, id string) ([]Secret, error) {
+	var secrets []Secret
+	all, err := b.vault(ctx)
+	if err != nil {
+		return secrets, err
+	}
+	for _, s := range all {
+		if s.ID == id {
+			secrets = append(secrets, s)
+		}
+	}
+	if len(secrets) == 0 {
+		return secrets, b.missing(id)
+	}
+	return secrets, nil
+}
+
+// missing explains why id is not in the vault. Secrets in shared folders
+// exist, but can't be decrypted, and must not be reported as deleted.
+func (b *NativeBackend) missing(id string) error {
+	b.mu.Lock()
+	share, ok := b.shared[id]
+	b.mu.Unlock()
+	if ok {
+		return &Error{Err: ErrUnsupported, Message: fmt.Sprintf("secret %s is in shared folder %s, the native backend can't decrypt shared folders yet, use the lpass backend", id, share)}
+	}
+	return &Error{Err: ErrNotFound, Message: id}
+}
+
+// List downloads the vault and returns all accounts.
+func (b *NativeBackend) List(ctx context.Context) ([]Secret, error) {
+	return b.vault(ctx)
+}
+
+// Update pushes changes to an existing account.
+func (b *NativeBackend) Update(ctx context.Context, s Secret) error {
+	_, err := b.Read(ctx, s.ID)
+	if err != nil {
+		return err
+	}
+	_, err = b.save(ctx, s.ID, s)
+	return err
+}
+
+// Rename saves an account under a new name, the folder is part of the name.
+func (b *NativeBackend) Rename(ctx context.Context, id, fullname string) error {
+	secrets, err := b.Read(ctx, id)
+	if err != nil {
+		return err
+	}
+	s := secrets[0]
+	s.Name = fullname
+	_, err = b.save(ctx, id, s)
+	return err
+}
+
+// Delete removes an account.
+func (b *NativeBackend) Delete(ctx context.Context, id string) error {
+	_, err := b.Read(ctx, id)
+	if err != nil {
+		return err
+	}
+	session, err := b.currentSession()
+	if err != nil {
+		return err
+	}
+	body, err := b.post(ctx, session, "/show_website.php", url.Values{
+		"extjs":  {"1"},
+		"token":  {session.token},
+		"method": {"cli"},
+		"delete": {"1"},
+		"aid":    {id},
+	})
+	if err != nil {
+		return err
+	}
+	var resp showWebsiteResponse
+	err = xml.Unmarshal(body, &resp)
+	if err != nil {
+		return err
+	}
+	if resp.Result.Msg != "accountdeleted" {
+		return fmt.Errorf("unable to delete secret: %q", body)
+	}
+	return nil
+}
+
+// save adds (aid "0") or edits an account and returns its ID.
+func (b *NativeBackend) save(ctx context.Context, aid string, s Secret) (string, error) {
+	session, err := b.currentSession()
+	if err != nil {
+		return "", err
+	}
+	s, err = secureNote(s)
+	if err != nil {
+		return "", err
+	}
+	group, name := splitName(s.Name)
+	params := url.Values{
+		"extjs":     {"1"},
+		"token":     {session.token},
+		"method":    {"cli"},
+		"pwprotect": {"off"},
+		"aid":       {aid},
+		"url":       {hex.EncodeToString([]byte(s.URL))},
+	}
+	fields := map[string]string{
+		"name":     name,
+		"grouping": group,
+		"username": s.Username,
+		"password": s.Password,
+		"extra":    s.Note,
+	}
+	for k, v := range fields {
+		enc, err := encryptItem(v, session.key)
+		if err != nil {
+			return "", err
+		}
+		params.Set(k, enc)
+	}
+	body, err := b.post(ctx, session, "/show_website.php", params)
+	if err != nil {
+		return "", err
+	}
+	var resp showWebsiteResponse
+	err = xml.Unmarshal(body, &resp)
+	if err != nil {
+		return "", err
+	}
+	if resp.Result.AID == "" || resp.Result.AID == "0" {
+		return "", fmt.Errorf("unable to save secret: %q", body)
+	}
+	return resp.Result.AID, nil
+}
+
+// vault downloads and decrypts the account list.
+func (b *NativeBackend) vault(ctx context.Context) ([]Secret, error) {
+	session, err := b.currentSession()
+	if err != nil {
+		return nil, err
+	}
+	body, err := b.get(ctx, session, "/getaccts.php", url.Values{
+		"mobile":     {"1"},
+		"b64":        {"1"},
+		"hash":       {"0.0"},
+		"hasplugin":  {"3.0.23"},
+		"requestsrc": {"cli"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	blob, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(body)))
+	if err != nil {
+		return nil, &Error{Err: ErrNotLoggedIn, Message: "unable to decode vault blob, session expired?"}
+	}
+	secrets, shared, err := parseBlob(blob, session.key)
+	if err != nil {
+		return nil, err
+	}
+	b.mu.Lock()
+	b.shared = shared
+	b.mu.Unlock()
+	return secrets, nil
+}
+
+func (b *NativeBackend) currentSession() (*nativeSession, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.session == nil {
+		return nil, &Error{Err: ErrNotLoggedIn}
+	}
+	return b.session, nil
+}
+
+func (b *NativeBackend) get(ctx context.Context, session *nativeSession, path string, params url.Values) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", b.url(path)+"?"+params.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	return b.do(session, req)
+}
+
+func (b *NativeBackend) post(ctx context.Context, session *nativeSession, path string, params url.Values) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", b.url(path), strings.NewReader(params.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return b.do(session, req)
+}
+
+func (b *NativeBackend) do(session *nativeSession, req *http.Request) ([]byte, error) {
+	req.Header.Set("User-Agent", "terraform-provider-lastpass")
+	if session != nil {
+		req.AddCookie(&http.Cookie{Name: "PHPSESSID", Value: session.sessionID})
+	}
+	client := b.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return nil, &Error{Err: ErrRateLimited, Message: req.URL.Path}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s %s: %s", req.Method, req.URL.Path, resp.Status)
+	}
+	return body, nil
+}
+
+func (b *NativeBackend) url(path string) string {
+	base := b.BaseURL
+	if base == "" {
+		base = defaultBaseURL
+	}
+	return strings.TrimSuffix(base, "/") + path
+}
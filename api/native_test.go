@@ -0,0 +1,427 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeLastpass is a minimal stand-in for the Lastpass HTTPS API.
+type fakeLastpass struct {
+	t          *testing.T
+	username   string
+	password   string
+	iterations int
+	// otp is required at login when set
+	otp string
+	// outOfBand requires a push approval, after oobPolls retries
+	outOfBand bool
+	oobPolls  int
+
+	mu       sync.Mutex
+	accounts []Secret
+	lastID   int
+}
+
+func newFakeLastpass(t *testing.T) (*fakeLastpass, *httptest.Server) {
+	f := &fakeLastpass{
+		t:          t,
+		username:   "gopher@example.com",
+		password:   "hunter2",
+		iterations: 5000,
+		lastID:     1000,
+	}
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+	return f, srv
+}
+
+func (f *fakeLastpass) key() []byte {
+	return makeKey(f.username, f.password, f.iterations)
+}
+
+func (f *fakeLastpass) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	err := r.ParseForm()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if r.URL.Path != "/iterations.php" && r.URL.Path != "/login.php" {
+		c, err := r.Cookie("PHPSESSID")
+		if err != nil || c.Value != "s3ss10n" {
+			http.Error(w, "not logged in", http.StatusForbidden)
+			return
+		}
+	}
+	switch r.URL.Path {
+	case "/iterations.php":
+		fmt.Fprint(w, f.iterations)
+	case "/login.php":
+		iterations, _ := strconv.Atoi(r.PostForm.Get("iterations"))
+		if iterations != f.iterations {
+			fmt.Fprintf(w, `<response><error message="iterations changed" iterations="%d"/></response>`, f.iterations)
+			return
+		}
+		if r.PostForm.Get("hash") != makeLoginHash(f.key(), f.password, f.iterations) {
+			fmt.Fprint(w, `<response><error message="Invalid password!" cause="unknownpassword"/></response>`)
+			return
+		}
+		if f.otp != "" && r.PostForm.Get("otp") == "" {
+			fmt.Fprint(w, `<response><error message="Google Authenticator authentication required!" cause="googleauthrequired"/></response>`)
+			return
+		}
+		if f.otp != "" && r.PostForm.Get("otp") != f.otp {
+			fmt.Fprint(w, `<response><error message="Google Authenticator code is incorrect." cause="googleauthfailed"/></response>`)
+			return
+		}
+		if f.outOfBand {
+			if r.PostForm.Get("outofbandrequest") != "1" || f.oobPolls > 0 {
+				if r.PostForm.Get("outofbandrequest") == "1" {
+					f.oobPolls--
+				}
+				fmt.Fprint(w, `<response><error message="Multifactor authentication required!" cause="outofbandrequired" retryid="r3try"/></response>`)
+				return
+			}
+		}
+		fmt.Fprint(w, `<response><ok sessionid="s3ss10n" token="t0k3n"/></response>`)
+	case "/getaccts.php":
+		fmt.Fprint(w, base64.StdEncoding.EncodeToString(f.blob()))
+	case "/show_website.php":
+		if r.PostForm.Get("token") != "t0k3n" {
+			http.Error(w, "bad token", http.StatusForbidden)
+			return
+		}
+		f.showWebsite(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (f *fakeLastpass) showWebsite(w http.ResponseWriter, r *http.Request) {
+	aid := r.PostForm.Get("aid")
+	if r.PostForm.Get("delete") == "1" {
+		for i := range f.accounts {
+			if f.accounts[i].ID == aid {
+				f.accounts = append(f.accounts[:i], f.accounts[i+1:]...)
+				break
+			}
+		}
+		fmt.Fprint(w, `<xmlresponse><result msg="accountdeleted"/></xmlresponse>`)
+		return
+	}
+	decrypt := func(field string) string {
+		v, err := decryptBase64Item(r.PostForm.Get(field), f.key())
+		if err != nil {
+			f.t.Errorf("unable to decrypt %s: %s", field, err)
+		}
+		return v
+	}
+	url, _ := hex.DecodeString(r.PostForm.Get("url"))
+	s := Secret{
+		Name:     decrypt("name"),
+		Group:    decrypt("grouping"),
+		Username: decrypt("username"),
+		Password: decrypt("password"),
+		Note:     decrypt("extra"),
+		URL:      string(url),
+	}
+	action := "added"
+	if aid == "0" {
+		f.lastID++
+		s.ID = strconv.Itoa(f.lastID)
+		f.accounts = append(f.accounts, s)
+	} else {
+		action = "updated"
+		s.ID = aid
+		for i := range f.accounts {
+			if f.accounts[i].ID == aid {
+				f.accounts[i] = s
+			}
+		}
+	}
+	fmt.Fprintf(w, `<xmlresponse><result action="%s" aid="%s"/></xmlresponse>`, action, s.ID)
+}
+
+// blob serializes the accounts the same way getaccts.php does.
+func (f *fakeLastpass) blob() []byte {
+	var blob bytes.Buffer
+	writeChunk(&blob, "LPAV", []byte("42"))
+	for i, s := range f.accounts {
+		// alternate between ECB and CBC encrypted fields
+		cbc := i%2 == 0
+		items := make([][]byte, acctLastPwChangeGmt+1)
+		items[acctID] = []byte(s.ID)
+		items[acctName] = encryptRaw(s.Name, f.key(), cbc)
+		items[acctGroup] = encryptRaw(s.Group, f.key(), cbc)
+		items[acctURL] = []byte(hex.EncodeToString([]byte(s.URL)))
+		items[acctNote] = encryptRaw(s.Note, f.key(), cbc)
+		items[acctUsername] = encryptRaw(s.Username, f.key(), cbc)
+		items[acctPassword] = encryptRaw(s.Password, f.key(), cbc)
+		items[acctLastTouch] = []byte("1617280000")
+		items[acctLastModifiedGmt] = []byte("1617281234")
+		items[acctLastPwChangeGmt] = []byte("1617280500")
+		writeAccount(&blob, items)
+	}
+	// a folder, and a shared entry we can't decrypt
+	writeAccount(&blob, [][]byte{[]byte("1"), nil, encryptRaw("Folder", f.key(), true), []byte(hex.EncodeToString([]byte("http://group")))})
+	var share bytes.Buffer
+	writeItem(&share, []byte("77"))
+	writeItem(&share, []byte("encrypted share key"))
+	writeChunk(&blob, "SHAR", share.Bytes())
+	writeAccount(&blob, [][]byte{[]byte("2"), []byte("undecryptable")})
+	writeChunk(&blob, "ENDM", []byte("OK"))
+	return blob.Bytes()
+}
+
+func writeAccount(blob *bytes.Buffer, items [][]byte) {
+	var chunk bytes.Buffer
+	for _, item := range items {
+		writeItem(&chunk, item)
+	}
+	writeChunk(blob, "ACCT", chunk.Bytes())
+}
+
+func writeChunk(blob *bytes.Buffer, tag string, data []byte) {
+	blob.WriteString(tag)
+	writeItem(blob, data)
+}
+
+func writeItem(blob *bytes.Buffer, data []byte) {
+	size := make([]byte, 4)
+	binary.BigEndian.PutUint32(size, uint32(len(data)))
+	blob.Write(size)
+	blob.Write(data)
+}
+
+// encryptRaw encrypts a value in the binary form used inside the vault blob.
+func encryptRaw(value string, key []byte, cbc bool) []byte {
+	if value == "" {
+		return nil
+	}
+	block, _ := aes.NewCipher(key)
+	plain := pad([]byte(value))
+	out := make([]byte, len(plain))
+	if !cbc {
+		for i := 0; i < len(plain); i += aes.BlockSize {
+			block.Encrypt(out[i:i+aes.BlockSize], plain[i:i+aes.BlockSize])
+		}
+		return out
+	}
+	iv := bytes.Repeat([]byte{7}, aes.BlockSize)
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(out, plain)
+	return append(append([]byte{'!'}, iv...), out...)
+}
+
+func TestNativeBackend(t *testing.T) {
+	f, srv := newFakeLastpass(t)
+	f.accounts = []Secret{{ID: "999", Name: "existing", Group: "Folder", Username: "root", Password: "toor", URL: "https://example.com"}}
+	client := Client{
+		Username: f.username,
+		Password: f.password,
+		Backend:  &NativeBackend{BaseURL: srv.URL},
+	}
+	secrets, err := client.Read("999")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(secrets) != 1 {
+		t.Fatalf("expected 1 secret, got %d", len(secrets))
+	}
+	if secrets[0].Fullname != "Folder/existing" || secrets[0].Username != "root" || secrets[0].Password != "toor" || secrets[0].URL != "https://example.com" {
+		t.Errorf("Read() returned unexpected secret: %+v", secrets[0])
+	}
+	if secrets[0].LastModifiedGmt != "1617281234" {
+		t.Errorf("unexpected last_modified_gmt %q", secrets[0].LastModifiedGmt)
+	}
+	if secrets[0].LastPasswordChangeGmt != "1617280500" {
+		t.Errorf("unexpected last_pwchange_gmt %q", secrets[0].LastPasswordChangeGmt)
+	}
+	found, err := client.Find(Selector{Name: "existing", URL: "https://example.com"})
+	if err != nil || found.ID != "999" {
+		t.Errorf("Find() returned %+v, %v", found, err)
+	}
+
+	s, err := client.Create(Secret{
+		Name:     "Infra/mysecret",
+		Username: "user",
+		Password: "pw",
+		Note:     "ABC\nDEF",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.ID != "1001" || s.Group != "Infra" || s.Name != "Infra/mysecret" || s.Note != "ABC\nDEF" {
+		t.Errorf("Create() returned unexpected secret: %+v", s)
+	}
+	s.Password = "pw2"
+	err = client.Update(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secrets, err = client.Read(s.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(secrets) != 1 || secrets[0].Password != "pw2" {
+		t.Errorf("Update() was not applied: %+v", secrets)
+	}
+	err = client.Update(Secret{ID: "4242", Name: "missing"})
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected not found error, got %v", err)
+	}
+	err = client.Delete(s.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secrets, _ = client.Read(s.ID)
+	if len(secrets) != 0 {
+		t.Error("secret still exists after Delete()")
+	}
+	err = client.Delete(s.ID)
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected not found error, got %v", err)
+	}
+}
+
+func TestNativeBackendLogin(t *testing.T) {
+	ctx := context.Background()
+	f, srv := newFakeLastpass(t)
+	b := &NativeBackend{BaseURL: srv.URL}
+	err := b.Login(ctx, Credentials{Username: f.username, Password: "wrong"})
+	if !errors.Is(err, ErrAuthFailed) || !strings.Contains(err.Error(), "Invalid password") {
+		t.Errorf("expected invalid password error, got %v", err)
+	}
+	err = b.Login(ctx, Credentials{})
+	if !errors.Is(err, ErrNotLoggedIn) {
+		t.Errorf("expected not logged in error without credentials, got %v", err)
+	}
+	// the server tells us about a new iteration count during login
+	f.iterations = 1
+	err = b.Login(ctx, Credentials{Username: f.username, Password: f.password})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = b.Read(ctx, "2")
+	if !errors.Is(err, ErrUnsupported) || !strings.Contains(err.Error(), "shared folder 77") {
+		t.Errorf("expected unsupported error for a secret in a shared folder, got %v", err)
+	}
+	_, err = b.Read(ctx, "3")
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected not found error, got %v", err)
+	}
+	folders, err := b.Read(ctx, "1")
+	if err != nil || !folders[0].IsFolder() || folders[0].Fullname != "Folder/" {
+		t.Errorf("expected folder entry, got %+v, %v", folders, err)
+	}
+}
+
+func TestNativeBackendLoginMFA(t *testing.T) {
+	ctx := context.Background()
+	f, srv := newFakeLastpass(t)
+	f.otp = "123456"
+	b := &NativeBackend{BaseURL: srv.URL}
+	err := b.Login(ctx, Credentials{Username: f.username, Password: f.password})
+	if !errors.Is(err, ErrMFARequired) {
+		t.Errorf("expected MFA required error, got %v", err)
+	}
+	err = b.Login(ctx, Credentials{Username: f.username, Password: f.password, OTP: "000000"})
+	if !errors.Is(err, ErrInvalidOTP) {
+		t.Errorf("expected invalid OTP error, got %v", err)
+	}
+	err = b.Login(ctx, Credentials{Username: f.username, Password: f.password, OTP: "123456"})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestNativeBackendLoginOutOfBand(t *testing.T) {
+	ctx := context.Background()
+	f, srv := newFakeLastpass(t)
+	f.outOfBand = true
+	f.oobPolls = 3
+	retry := RetryPolicy{InitialDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond, Deadline: time.Second}
+	b := &NativeBackend{BaseURL: srv.URL, OutOfBandRetry: retry}
+	err := b.Login(ctx, Credentials{Username: f.username, Password: f.password})
+	if !errors.Is(err, ErrMFARequired) {
+		t.Errorf("expected MFA required error without out_of_band, got %v", err)
+	}
+	err = b.Login(ctx, Credentials{Username: f.username, Password: f.password, OutOfBand: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// never approved, polls back off until the deadline
+	f.oobPolls = maxLoginAttempts
+	b = &NativeBackend{BaseURL: srv.URL, OutOfBandRetry: RetryPolicy{InitialDelay: 20 * time.Millisecond, MaxDelay: 40 * time.Millisecond, Deadline: 200 * time.Millisecond}}
+	start := time.Now()
+	err = b.Login(ctx, Credentials{Username: f.username, Password: f.password, OutOfBand: true})
+	if !errors.Is(err, ErrMFARequired) || !strings.Contains(err.Error(), "not approved") {
+		t.Errorf("expected timeout error, got %v", err)
+	}
+	if polls := maxLoginAttempts - f.oobPolls; polls > 8 || time.Since(start) < 100*time.Millisecond {
+		t.Errorf("expected a few polls with backoff, got %d in %s", polls, time.Since(start))
+	}
+	// cancelled while waiting
+	b = &NativeBackend{BaseURL: srv.URL, OutOfBandRetry: RetryPolicy{InitialDelay: time.Minute, Deadline: time.Hour}}
+	cctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	err = b.Login(cctx, Credentials{Username: f.username, Password: f.password, OutOfBand: true})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the login to stop when ctx is done, got %v", err)
+	}
+}
+
+func TestNativeBackendCancel(t *testing.T) {
+	f, srv := newFakeLastpass(t)
+	client := Client{
+		Username: f.username,
+		Password: f.password,
+		Backend:  &NativeBackend{BaseURL: srv.URL},
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := client.ReadContext(ctx, "1")
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected canceled error, got %v", err)
+	}
+}
+
+func TestEncryptItem(t *testing.T) {
+	key := makeKey("user", "pass", 5000)
+	for _, v := range []string{"", "a", "exactly 16 bytes", "multi\nline\nvalue ✓"} {
+		enc, err := encryptItem(v, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		dec, err := decryptBase64Item(enc, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if dec != v {
+			t.Errorf("round trip failed, got %q want %q", dec, v)
+		}
+		for _, cbc := range []bool{true, false} {
+			dec, err = decryptItem(encryptRaw(v, key, cbc), key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if dec != v {
+				t.Errorf("raw round trip (cbc=%t) failed, got %q want %q", cbc, dec, v)
+			}
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package api
+
+import (
+	"fmt"
+	"strings"
+)
+
+// NoteField is a named field of a secure note or of a lpass edit template.
+type NoteField struct {
+	Name  string
+	Value string
+}
+
+// Note is the text of a secure note split into its fields, e.g.
+//
+//	NoteType:Server
+//	Language:en-US
+//	Hostname:example.com
+//	Notes:free text
+//
+// Lastpass has no escaping, a value spans several lines until a line that
+// starts a new field, see fieldStart. The notes are the rest of the text.
+type Note struct {
+	Type   string
+	Fields []NoteField
+	Notes  string
+}
+
+// ParseNote splits the text of a secure note into its fields, ok is false
+// when the text is not a secure note.
+func ParseNote(text string) (n Note, ok bool) {
+	if !strings.HasPrefix(text, "NoteType:") {
+		return n, false
+	}
+	lines := strings.Split(text, "\n")
+	n.Type = strings.TrimPrefix(lines[0], "NoteType:")
+	for i := 1; i < len(lines); i++ {
+		name, value, ok := fieldStart(lines[i], n.Type)
+		switch {
+		case ok && name == "Notes":
+			n.Notes = strings.Join(append([]string{value}, lines[i+1:]...), "\n")
+			return n, true
+		case ok:
+			n.Fields = append(n.Fields, NoteField{name, value})
+		case len(n.Fields) > 0:
+			n.Fields[len(n.Fields)-1].Value += "\n" + lines[i]
+		}
+	}
+	return n, true
+}
+
+// FormatNote renders a secure note the way Lastpass stores it. It fails for
+// notes that would not parse back the same.
+func FormatNote(n Note) (string, error) {
+	if n.Type == "" || strings.Contains(n.Type, "\n") {
+		return "", fmt.Errorf("invalid note type %q", n.Type)
+	}
+	err := checkFields(n.Type, n.Fields)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	b.WriteString("NoteType:" + n.Type + "\n")
+	for _, f := range n.Fields {
+		b.WriteString(f.Name + ":" + f.Value + "\n")
+	}
+	b.WriteString("Notes:" + n.Notes)
+	return b.String(), nil
+}
+
+// Template is the text lpass add and edit read on stdin, e.g.
+//
+//	Name: Infra/db
+//	URL: https://example.com
+//	Username: gopher
+//	Password: hunter2
+//	Notes:    # Add notes below this line.
+//	free text
+//
+// Secure notes list the fields of their template instead of URL, Username
+// and Password.
+type Template struct {
+	Name   string
+	Fields []NoteField
+	Notes  string
+}
+
+// notesHeader starts the notes of a template.
+const notesHeader = "Notes:    # Add notes below this line."
+
+// ParseTemplate reads a template for a secret of the given note type, empty
+// for sites.
+func ParseTemplate(text, noteType string) Template {
+	var t Template
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if i == 0 && strings.HasPrefix(line, "Name:") {
+			t.Name = strings.TrimPrefix(strings.TrimPrefix(line, "Name:"), " ")
+			continue
+		}
+		name, value, ok := fieldStart(line, noteType)
+		switch {
+		case ok && name == "Notes":
+			t.Notes = strings.TrimSuffix(strings.Join(lines[i+1:], "\n"), "\n")
+			return t
+		case ok:
+			t.Fields = append(t.Fields, NoteField{name, strings.TrimPrefix(value, " ")})
+		case len(t.Fields) > 0:
+			t.Fields[len(t.Fields)-1].Value += "\n" + line
+		}
+	}
+	return t
+}
+
+// FormatTemplate renders a template for a secret of the given note type,
+// empty for sites. It fails for templates that would not parse back the same.
+func FormatTemplate(t Template, noteType string) (string, error) {
+	if strings.Contains(t.Name, "\n") {
+		return "", fmt.Errorf("name %q contains a new line", t.Name)
+	}
+	if noteType == "" {
+		for _, f := range t.Fields {
+			if strings.Contains(f.Value, "\n") {
+				return "", fmt.Errorf("lpass can't store new lines in the %s of a site", strings.ToLower(f.Name))
+			}
+		}
+	}
+	err := checkFields(noteType, t.Fields)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	b.WriteString("Name: " + t.Name + "\n")
+	for _, f := range t.Fields {
+		b.WriteString(f.Name + ": " + f.Value + "\n")
+	}
+	b.WriteString(notesHeader + "\n" + t.Notes + "\n")
+	return b.String(), nil
+}
+
+// fieldStart splits a line starting a new field into its name and value at
+// the first colon, the way Lastpass does. Templates with known fields only
+// start a field with one of them, other lines continue the field above.
+func fieldStart(line, noteType string) (name, value string, ok bool) {
+	i := strings.Index(line, ":")
+	if i <= 0 {
+		return "", "", false
+	}
+	if fields, known := templateFields(noteType); known && !stringInSlice(line[:i], fields) {
+		return "", "", false
+	}
+	return line[:i], line[i+1:], true
+}
+
+// checkFields makes sure the fields parse back the same: names can't
+// contain the colon ending them, and no line of a value can be mistaken for
+// the start of a field.
+func checkFields(noteType string, fields []NoteField) error {
+	for _, f := range fields {
+		switch {
+		case f.Name == "" || strings.ContainsAny(f.Name, ":\n"):
+			return fmt.Errorf("invalid field name %q, it can't be empty or contain colons or new lines", f.Name)
+		case f.Name == "NoteType" || f.Name == "Notes":
+			return fmt.Errorf("%s is not a field", f.Name)
+		case !isNoteField(noteType, f.Name):
+			return fmt.Errorf("%q is not a field of %s notes", f.Name, noteType)
+		}
+		lines := strings.Split(f.Value, "\n")
+		for _, line := range lines[1:] {
+			if name, _, ok := fieldStart(line, noteType); ok {
+				return fmt.Errorf("a line of %s would start the field %s: %q", f.Name, name, line)
+			}
+		}
+	}
+	return nil
+}
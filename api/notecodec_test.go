@@ -0,0 +1,157 @@
+package api
+
+import (
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/quick"
+)
+
+// noteFragments are pieces of values likely to confuse the parser.
+var noteFragments = []string{"a", "B", " ", ":", "\n", "Notes", "Notes:", "NoteType:", "Hostname", "Private Key", "Language:", "-----BEGIN", "é", "\t"}
+
+func randomValue(r *rand.Rand) string {
+	var b strings.Builder
+	for i := r.Intn(12); i > 0; i-- {
+		b.WriteString(noteFragments[r.Intn(len(noteFragments))])
+	}
+	return b.String()
+}
+
+// randomNote is a note of a template with known fields, or of one without.
+type randomNote Note
+
+func (randomNote) Generate(r *rand.Rand, size int) reflect.Value {
+	n := Note{Type: "Server", Notes: randomValue(r)}
+	names := []string{"Hostname", "Port", "Username", "Password", "Custom"}
+	if r.Intn(2) == 0 {
+		n.Type = "SSH Key"
+		names = append(NoteTypeFields("SSH Key"), "Username", "Password")
+	}
+	for i := r.Intn(5); i > 0; i-- {
+		n.Fields = append(n.Fields, NoteField{names[r.Intn(len(names))], randomValue(r)})
+	}
+	return reflect.ValueOf(randomNote(n))
+}
+
+func TestNoteRoundTrip(t *testing.T) {
+	formatted := 0
+	property := func(rn randomNote) bool {
+		n := Note(rn)
+		text, err := FormatNote(n)
+		if err != nil {
+			// only values with a line starting a field can't be stored
+			return strings.Contains(err.Error(), "would start the field")
+		}
+		formatted++
+		got, ok := ParseNote(text)
+		return ok && got.Type == n.Type && got.Notes == n.Notes && equalFields(got.Fields, n.Fields)
+	}
+	if err := quick.Check(property, &quick.Config{MaxCount: 2000}); err != nil {
+		t.Error(err)
+	}
+	if formatted < 200 {
+		t.Errorf("only %d of 2000 notes could be formatted, the test is not checking much", formatted)
+	}
+}
+
+// randomTemplate is a template of a site or a secure note.
+type randomTemplate struct {
+	Template
+	NoteType string
+}
+
+func (randomTemplate) Generate(r *rand.Rand, size int) reflect.Value {
+	t := randomTemplate{Template: Template{Name: strings.Replace(randomValue(r), "\n", "/", -1), Notes: randomValue(r)}}
+	names := []string{"URL", "Username", "Password"}
+	if r.Intn(2) == 0 {
+		t.NoteType = "SSH Key"
+		names = NoteTypeFields("SSH Key")
+	}
+	for i := r.Intn(5); i > 0; i-- {
+		t.Fields = append(t.Fields, NoteField{names[r.Intn(len(names))], randomValue(r)})
+	}
+	return reflect.ValueOf(t)
+}
+
+func TestTemplateRoundTrip(t *testing.T) {
+	formatted := 0
+	property := func(rt randomTemplate) bool {
+		text, err := FormatTemplate(rt.Template, rt.NoteType)
+		if err != nil {
+			return strings.Contains(err.Error(), "would start the field") || strings.Contains(err.Error(), "can't store new lines")
+		}
+		formatted++
+		got := ParseTemplate(text, rt.NoteType)
+		return got.Name == rt.Name && got.Notes == rt.Notes && equalFields(got.Fields, rt.Fields)
+	}
+	if err := quick.Check(property, &quick.Config{MaxCount: 2000}); err != nil {
+		t.Error(err)
+	}
+	if formatted < 200 {
+		t.Errorf("only %d of 2000 templates could be formatted, the test is not checking much", formatted)
+	}
+}
+
+func equalFields(a, b []NoteField) bool {
+	return len(a) == len(b) && (len(a) == 0 || reflect.DeepEqual(a, b))
+}
+
+func TestParseNote(t *testing.T) {
+	for _, tc := range []struct {
+		text string
+		want Note
+	}{
+		{"NoteType:Server\nHostname:example.com\nNotes:", Note{Type: "Server", Fields: []NoteField{{"Hostname", "example.com"}}}},
+		{"NoteType:Server\nURL:http://a:8080/x\nNotes:a\nNotes:b", Note{Type: "Server", Fields: []NoteField{{"URL", "http://a:8080/x"}}, Notes: "a\nNotes:b"}},
+		{"NoteType:SSH Key\nPrivate Key:-----BEGIN\nabc:def\n-----END\nHostname:h\nNotes:", Note{Type: "SSH Key", Fields: []NoteField{{"Private Key", "-----BEGIN\nabc:def\n-----END"}, {"Hostname", "h"}}}},
+	} {
+		got, ok := ParseNote(tc.text)
+		if !ok || !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("ParseNote(%q) = %+v, want %+v", tc.text, got, tc.want)
+		}
+	}
+	if _, ok := ParseNote("just a note"); ok {
+		t.Error("plain notes are not secure notes")
+	}
+}
+
+func TestFormatTemplate(t *testing.T) {
+	s := Secret{Name: "db", Username: "gopher", Password: "hunter2"}
+	template, err := s.getTemplate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "Name: db\nURL: \nUsername: gopher\nPassword: hunter2\nNotes:    # Add notes below this line.\n\n"
+	if template != want {
+		t.Errorf("got %q, want %q", template, want)
+	}
+	s.Password = "hunter2\nhunter3"
+	if _, err := s.getTemplate(); err == nil {
+		t.Error("expected an error for a password with a new line")
+	}
+	if _, err := FormatNote(Note{Type: "Server", Fields: []NoteField{{"a:b", "c"}}}); err == nil {
+		t.Error("expected an error for a field name with a colon")
+	}
+}
+
+func TestNoteFieldNameColon(t *testing.T) {
+	for _, noteType := range []string{"Server", "SSH Key"} {
+		if _, err := FormatNote(Note{Type: noteType, Fields: []NoteField{{"Private Key:Id", "c"}}}); err == nil {
+			t.Errorf("%s: expected an error for a field name with a colon", noteType)
+		}
+	}
+	// a colon in a value only ends a name when the line starts a known field
+	n := Note{Type: "SSH Key", Fields: []NoteField{{"Private Key", "-----BEGIN\nProc-Type: 4,ENCRYPTED\n-----END"}}}
+	text, err := FormatNote(n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := ParseNote(text); !ok || !reflect.DeepEqual(got, n) {
+		t.Errorf("ParseNote(%q) = %+v, want %+v", text, got, n)
+	}
+	if _, err := FormatNote(Note{Type: "Server", Fields: n.Fields}); err == nil {
+		t.Error("expected an error for a line that starts a field of a template without known fields")
+	}
+}
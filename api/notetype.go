@@ -0,0 +1,126 @@
+package api
+
+import (
+	"sort"
+)
+
+// NoteTypes maps secure note templates, as shown in the NoteType field of a
+// note, to the name lpass add --note-type expects.
+var NoteTypes = map[string]string{
+	"Address":           "address",
+	"American Express":  "amex",
+	"Bank Account":      "bank",
+	"Credit Card":       "credit-card",
+	"Database":          "database",
+	"Driver's License":  "drivers-license",
+	"Email Account":     "email",
+	"Health Insurance":  "health-insurance",
+	"Instant Messenger": "im",
+	"Insurance":         "insurance",
+	"Mastercard":        "mastercard",
+	"Membership":        "membership",
+	"Passport":          "passport",
+	"Server":            "server",
+	"Software License":  "software-license",
+	"SSH Key":           "ssh-key",
+	"Social Security":   "ssn",
+	"VISA":              "visa",
+	"Wi-Fi Password":    "wifi",
+}
+
+// noteTypeFields lists the fields of templates with values spanning several
+// lines, e.g. a private key. Lines not starting with one of these belong to
+// the field above them.
+var noteTypeFields = map[string][]string{
+	"SSH Key":         {"Bit Strength", "Format", "Passphrase", "Private Key", "Public Key", "Hostname", "Date"},
+	apiCredentialType: {"Endpoint", "Key ID", "Secret", "Expires"},
+}
+
+// NoteTypeFields returns the fields of a template, if they are known.
+func NoteTypeFields(noteType string) []string {
+	return noteTypeFields[noteType]
+}
+
+// isNoteField reports whether key starts a new field of a note of the given
+// type. Templates without a list of fields take any key, and every note can
+// have a username and password.
+func isNoteField(noteType, key string) bool {
+	fields, ok := templateFields(noteType)
+	if !ok {
+		return true
+	}
+	return stringInSlice(key, fields)
+}
+
+// templateFields returns every field a note of the given type can start a
+// line with, ok is false for templates without a list of fields.
+func templateFields(noteType string) (fields []string, ok bool) {
+	known, ok := noteTypeFields[noteType]
+	if !ok {
+		return nil, false
+	}
+	return append([]string{"NoteType", "Language", "Notes", "Username", "Password"}, known...), true
+}
+
+// noteURL is the URL Lastpass uses for secure notes.
+const noteURL = "http://sn"
+
+// NoteTypeNames returns the supported note types in sorted order.
+func NoteTypeNames() []string {
+	var names []string
+	for name := range NoteTypes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// noteFields returns the fields of a secure note in a stable order,
+// Username and Password first, the same way Lastpass lists them.
+func (s *Secret) noteFields() [][2]string {
+	var fields [][2]string
+	if s.Username != "" {
+		fields = append(fields, [2]string{"Username", s.Username})
+	}
+	if s.Password != "" {
+		fields = append(fields, [2]string{"Password", s.Password})
+	}
+	var keys []string
+	for k := range s.CustomFields {
+		if k == "Username" && s.Username != "" || k == "Password" && s.Password != "" {
+			continue
+		}
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fields = append(fields, [2]string{k, s.CustomFields[k]})
+	}
+	return fields
+}
+
+// noteText renders a secure note the way Lastpass stores it.
+func (s *Secret) noteText() (string, error) {
+	fields := []NoteField{{"Language", "en-US"}}
+	for _, f := range s.noteFields() {
+		fields = append(fields, NoteField{f[0], f[1]})
+	}
+	return FormatNote(Note{Type: s.NoteType, Fields: fields, Notes: s.Note})
+}
+
+// secureNote moves the fields of a secure note into its note text, the way
+// Lastpass stores them, for backends writing the note themselves.
+func secureNote(s Secret) (Secret, error) {
+	if s.NoteType == "" {
+		return s, nil
+	}
+	note, err := s.noteText()
+	if err != nil {
+		return s, err
+	}
+	s.Note = note
+	s.URL = noteURL
+	s.Username, s.Password = "", ""
+	s.CustomFields = nil
+	return s, nil
+}
@@ -0,0 +1,201 @@
+package api
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// SyncBackend is implemented by backends able to hold back the upload of
+// changes, so the write queue can send a burst of changes in one sync.
+type SyncBackend interface {
+	// Sync uploads the changes made with a deferred sync context and waits
+	// for Lastpass to accept them.
+	Sync(ctx context.Context) error
+}
+
+type deferSyncKey struct{}
+
+// deferSync marks ctx so backends leave the upload of a change to Sync.
+func deferSync(ctx context.Context) context.Context {
+	return context.WithValue(ctx, deferSyncKey{}, true)
+}
+
+// syncDeferred reports whether the change made with ctx is uploaded by a
+// later Sync.
+func syncDeferred(ctx context.Context) bool {
+	v, _ := ctx.Value(deferSyncKey{}).(bool)
+	return v
+}
+
+// QueueStats describes the changes that went through the write queue.
+type QueueStats struct {
+	// Writes is the number of changes made.
+	Writes int
+	// Coalesced is the number of changes uploaded by a shared sync.
+	Coalesced int
+	// Syncs is the number of shared syncs.
+	Syncs int
+	// Wait is the total time changes spent waiting in the queue.
+	Wait time.Duration
+	// MaxWait is the longest time a single change waited.
+	MaxWait time.Duration
+}
+
+// syncBatch is a burst of changes waiting for a shared sync.
+type syncBatch struct {
+	running int
+	size    int
+	done    chan struct{}
+	err     error
+	// orphaned is closed when a change gave up waiting to join the batch
+	// and left it to the changes in it to sync.
+	orphaned chan struct{}
+	claimed  bool
+}
+
+// QueueStats returns how the write queue has been used so far.
+func (c *Client) QueueStats() QueueStats {
+	c.queueMu.Lock()
+	defer c.queueMu.Unlock()
+	return c.stats
+}
+
+// write runs fn, a change to the vault, through the write queue. At most
+// MaxWrites changes run at once. When changes pile up in the queue and the
+// backend implements SyncBackend, they are made with a deferred sync and
+// uploaded together once the queue drains; each waits for that sync.
+func (c *Client) write(ctx context.Context, op string, fn func(ctx context.Context) error) error {
+	return c.queue(ctx, op, true, fn)
+}
+
+// writeNow is like write for changes that can't wait for a shared sync,
+// e.g. creates that need the ID Lastpass assigns when the secret is synced,
+// and share changes lpass sends to Lastpass straight away.
+func (c *Client) writeNow(ctx context.Context, op string, fn func(ctx context.Context) error) error {
+	return c.queue(ctx, op, false, fn)
+}
+
+func (c *Client) queue(ctx context.Context, op string, deferrable bool, change func(ctx context.Context) error) error {
+	fn := func(ctx context.Context) error {
+		return c.checkSession(change(ctx))
+	}
+	c.queueMu.Lock()
+	if c.slots == nil {
+		n := c.MaxWrites
+		if n < 1 {
+			n = 1
+		}
+		c.slots = make(chan struct{}, n)
+	}
+	slots := c.slots
+	c.waiting++
+	c.queueMu.Unlock()
+
+	start := time.Now()
+	select {
+	case slots <- struct{}{}:
+	case <-ctx.Done():
+		c.queueMu.Lock()
+		c.waiting--
+		batch := c.drained()
+		c.queueMu.Unlock()
+		if batch != nil {
+			// the changes in the batch still wait for their sync, one of
+			// them runs it with its own context
+			close(batch.orphaned)
+		}
+		return ctx.Err()
+	}
+	wait := time.Since(start)
+
+	_, canDefer := c.backend().(SyncBackend)
+	c.queueMu.Lock()
+	c.waiting--
+	c.stats.Writes++
+	c.stats.Wait += wait
+	if wait > c.stats.MaxWait {
+		c.stats.MaxWait = wait
+	}
+	batch := c.batch
+	if !deferrable || !canDefer {
+		batch = nil
+	} else if batch != nil || c.waiting > 0 {
+		if batch == nil {
+			batch = &syncBatch{done: make(chan struct{}), orphaned: make(chan struct{})}
+			c.batch = batch
+		}
+		batch.running++
+		batch.size++
+	}
+	waiting := c.waiting
+	c.queueMu.Unlock()
+	log.Printf("[DEBUG] lastpass: %s waited %s in the write queue, %d more waiting", op, wait, waiting)
+
+	if batch == nil {
+		err := fn(ctx)
+		<-slots
+		c.queueMu.Lock()
+		drained := c.drained()
+		c.queueMu.Unlock()
+		if drained != nil {
+			c.flush(ctx, drained)
+		}
+		return err
+	}
+	err := fn(deferSync(ctx))
+	<-slots
+	c.queueMu.Lock()
+	batch.running--
+	if c.drained() == batch {
+		c.queueMu.Unlock()
+		c.flush(ctx, batch)
+	} else {
+		c.queueMu.Unlock()
+	}
+	// The change is made, it only counts once the batch is synced. Wait for
+	// that even when ctx is done, the sync itself gives up with ctx.
+	select {
+	case <-batch.done:
+	case <-batch.orphaned:
+		c.queueMu.Lock()
+		claim := !batch.claimed
+		batch.claimed = true
+		c.queueMu.Unlock()
+		if claim {
+			c.flush(ctx, batch)
+		}
+		<-batch.done
+	}
+	if err != nil {
+		return err
+	}
+	return batch.err
+}
+
+// drained takes the current batch out of the queue once no change in it is
+// running and nothing waits to join it. queueMu must be held.
+func (c *Client) drained() *syncBatch {
+	batch := c.batch
+	if batch == nil || batch.running > 0 || c.waiting > 0 {
+		return nil
+	}
+	c.batch = nil
+	return batch
+}
+
+// flush uploads the changes of batch with a single sync.
+func (c *Client) flush(ctx context.Context, batch *syncBatch) {
+	defer close(batch.done)
+	syncer, ok := c.backend().(SyncBackend)
+	if !ok {
+		return
+	}
+	start := time.Now()
+	batch.err = c.checkSession(syncer.Sync(ctx))
+	c.queueMu.Lock()
+	c.stats.Syncs++
+	c.stats.Coalesced += batch.size
+	c.queueMu.Unlock()
+	log.Printf("[DEBUG] lastpass: synced %d queued changes in %s", batch.size, time.Since(start))
+}
@@ -0,0 +1,272 @@
+package api
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// gatedBackend holds updates until the gate opens and records how they sync.
+type gatedBackend struct {
+	*MemoryBackend
+	entered chan struct{}
+	gate    chan struct{}
+
+	mu       sync.Mutex
+	deferred []bool
+	created  []bool
+	syncs    int
+}
+
+func (b *gatedBackend) Create(ctx context.Context, s Secret) (Secret, error) {
+	b.mu.Lock()
+	b.created = append(b.created, syncDeferred(ctx))
+	b.mu.Unlock()
+	return b.MemoryBackend.Create(ctx, s)
+}
+
+func (b *gatedBackend) Update(ctx context.Context, s Secret) error {
+	b.mu.Lock()
+	b.deferred = append(b.deferred, syncDeferred(ctx))
+	b.mu.Unlock()
+	b.entered <- struct{}{}
+	<-b.gate
+	return b.MemoryBackend.Update(ctx, s)
+}
+
+func (b *gatedBackend) Sync(ctx context.Context) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.syncs++
+	return nil
+}
+
+func waitQueued(t *testing.T, c *Client, n int) {
+	deadline := time.Now().Add(time.Second)
+	for {
+		c.queueMu.Lock()
+		waiting := c.waiting
+		c.queueMu.Unlock()
+		if waiting == n {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %d queued writes, got %d", n, waiting)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// waitBatched waits until the changes of the current batch are made and
+// wait for their sync.
+func waitBatched(t *testing.T, c *Client) {
+	deadline := time.Now().Add(time.Second)
+	for {
+		c.queueMu.Lock()
+		batch := c.batch
+		c.queueMu.Unlock()
+		if batch != nil && batch.running == 0 {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("expected a batch waiting for its sync")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestClientWriteQueue(t *testing.T) {
+	b := &gatedBackend{MemoryBackend: &MemoryBackend{}, entered: make(chan struct{}, 3), gate: make(chan struct{})}
+	client := Client{Backend: b}
+	var ids []string
+	for _, name := range []string{"a", "b", "c"} {
+		s, err := client.Create(Secret{Name: name})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, s.ID)
+	}
+	var wg sync.WaitGroup
+	errs := make(chan error, 3)
+	update := func(id string) {
+		defer wg.Done()
+		errs <- client.Update(Secret{ID: id, Name: id, Password: "changed"})
+	}
+	wg.Add(1)
+	go update(ids[0])
+	<-b.entered
+	wg.Add(2)
+	go update(ids[1])
+	go update(ids[2])
+	waitQueued(t, &client, 2)
+	close(b.gate)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := fmtBools(b.deferred); got != "false true true" {
+		t.Errorf("expected the queued updates to defer their sync, got %s", got)
+	}
+	if b.syncs != 1 {
+		t.Errorf("expected 1 shared sync, got %d", b.syncs)
+	}
+	stats := client.QueueStats()
+	if stats.Writes != 6 || stats.Syncs != 1 || stats.Coalesced != 2 {
+		t.Errorf("unexpected queue stats %+v", stats)
+	}
+	if stats.MaxWait <= 0 || stats.Wait < stats.MaxWait {
+		t.Errorf("expected wait times to be recorded, got %+v", stats)
+	}
+}
+
+func TestClientWriteQueueCancel(t *testing.T) {
+	b := &gatedBackend{MemoryBackend: &MemoryBackend{}, entered: make(chan struct{}, 1), gate: make(chan struct{})}
+	client := Client{Backend: b}
+	s, err := client.Create(Secret{Name: "a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	running := make(chan error)
+	go func() {
+		running <- client.Update(s)
+	}()
+	<-b.entered
+	ctx, cancel := context.WithCancel(context.Background())
+	queued := make(chan error)
+	go func() {
+		queued <- client.UpdateContext(ctx, s)
+	}()
+	waitQueued(t, &client, 1)
+	cancel()
+	if err := <-queued; err != context.Canceled {
+		t.Errorf("expected the queued update to be cancelled, got %v", err)
+	}
+	close(b.gate)
+	if err := <-running; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestClientWriteQueueOrphaned(t *testing.T) {
+	b := &gatedBackend{MemoryBackend: &MemoryBackend{}}
+	client := Client{Backend: b}
+	if _, err := client.Create(Secret{Name: "a"}); err != nil {
+		t.Fatal(err)
+	}
+	// hold the only slot so the changes below queue up in order
+	client.slots <- struct{}{}
+	ctx2, cancel2 := context.WithCancel(context.Background())
+	batched := make(chan error)
+	go func() {
+		batched <- client.write(ctx2, "batched", func(ctx context.Context) error { return nil })
+	}()
+	waitQueued(t, &client, 1)
+	// a create keeps the slot once the batched change is made, so the
+	// change queued after it can't join the batch
+	release := make(chan struct{})
+	created := make(chan error)
+	go func() {
+		created <- client.writeNow(context.Background(), "create", func(ctx context.Context) error {
+			<-release
+			return nil
+		})
+	}()
+	waitQueued(t, &client, 2)
+	ctx3, cancel3 := context.WithCancel(context.Background())
+	queued := make(chan error)
+	go func() {
+		queued <- client.write(ctx3, "queued", func(ctx context.Context) error { return nil })
+	}()
+	waitQueued(t, &client, 3)
+	<-client.slots
+	waitBatched(t, &client)
+	// the batched change is made, it waits for the sync even when its
+	// context is done
+	cancel2()
+	select {
+	case err := <-batched:
+		t.Fatalf("expected the batched change to wait for its sync, got %v", err)
+	case <-time.After(10 * time.Millisecond):
+	}
+	// the queued change gives up and leaves the sync to the batch
+	cancel3()
+	if err := <-queued; err != context.Canceled {
+		t.Errorf("expected the queued change to be cancelled, got %v", err)
+	}
+	if err := <-batched; err != nil {
+		t.Errorf("expected the batched change to be synced, got %v", err)
+	}
+	close(release)
+	if err := <-created; err != nil {
+		t.Fatal(err)
+	}
+	if b.syncs != 1 {
+		t.Errorf("expected 1 shared sync, got %d", b.syncs)
+	}
+}
+
+func TestClientWriteQueueCreate(t *testing.T) {
+	b := &gatedBackend{MemoryBackend: &MemoryBackend{}, entered: make(chan struct{}, 2), gate: make(chan struct{})}
+	client := Client{Backend: b}
+	s, err := client.Create(Secret{Name: "a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	errs := make(chan error, 3)
+	go func() {
+		errs <- client.Update(s)
+	}()
+	<-b.entered
+	go func() {
+		errs <- client.Update(s)
+	}()
+	go func() {
+		_, err := client.Create(Secret{Name: "b"})
+		errs <- err
+	}()
+	waitQueued(t, &client, 2)
+	close(b.gate)
+	for i := 0; i < 3; i++ {
+		if err := <-errs; err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := fmtBools(b.created); got != "false false" {
+		t.Errorf("expected creates to sync straight away, got %s", got)
+	}
+}
+
+func TestCLIBackendDeferredSync(t *testing.T) {
+	f, b := newFakeLpass(t, fakeState{LoggedIn: true, Secrets: []fakeSecret{
+		{ID: "1", Fullname: "db"},
+	}})
+	err := b.Update(deferSync(context.Background()), Secret{ID: "1", Name: "db", Password: "pw"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = b.Sync(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	calls := f.state().Calls
+	if len(calls) != 2 || !strings.Contains(calls[0], "--sync=auto") || !strings.HasPrefix(calls[1], "sync") {
+		t.Errorf("expected a deferred edit and a sync, got %q", calls)
+	}
+}
+
+func fmtBools(v []bool) string {
+	var s []string
+	for _, b := range v {
+		if b {
+			s = append(s, "true")
+		} else {
+			s = append(s, "false")
+		}
+	}
+	return strings.Join(s, " ")
+}
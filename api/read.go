@@ -2,42 +2,45 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
-	"errors"
-	"os/exec"
 	"strings"
 )
 
-// Fetch secrets from upstream
-func (c *Client) Read(id string) ([]Secret, error) {
+// Read fetches secrets from upstream with lpass show.
+func (b *CLIBackend) Read(ctx context.Context, id string) ([]Secret, error) {
 	var secrets []Secret
-	err := c.login()
-	if err != nil {
-		return secrets, err
-	}
-	cmd := exec.Command("lpass", "show", "--sync=auto", "-G", id, "--json", "-x")
+	cmd := b.command(ctx, "show", "--sync=auto", "-G", id, "--json", "-x")
 	var outbuf, errbuf bytes.Buffer
 	cmd.Stdout = &outbuf
 	cmd.Stderr = &errbuf
-	err = cmd.Run()
+	err := cmd.Run()
 	if err != nil {
-		// Make sure the secret is not removed manually.
-		if strings.Contains(errbuf.String(), "Could not find specified account") {
-			// return empty secret list
-			return secrets, nil
-		}
-		var err = errors.New(errbuf.String())
-		return secrets, err
+		return secrets, commandError(ctx, errbuf.String())
 	}
 	err = json.Unmarshal(outbuf.Bytes(), &secrets)
 	if err != nil {
 		return secrets, err
 	}
+	// share_readonly is not typed consistently, read it on its own
+	var shares []struct {
+		ShareReadonly json.RawMessage `json:"share_readonly"`
+	}
+	err = json.Unmarshal(outbuf.Bytes(), &shares)
+	if err != nil {
+		return secrets, err
+	}
 	for i := range secrets {
-		if strings.Contains(secrets[i].Note, "\n") {
+		ro := strings.Trim(string(shares[i].ShareReadonly), `"`)
+		secrets[i].ShareReadonly = ro == "true" || ro == "1"
+		note := secrets[i].Note
+		if strings.HasPrefix(note, "NoteType:") {
+			// only the Notes section of a secure note can span several lines
+			note = note[strings.Index(note, "\nNotes:")+1:]
+		}
+		if strings.Contains(note, "\n") {
 			secrets[i].Note = secrets[i].Note + "\n" // lastpass trims new line, add back to multiline notes.
 		}
-		secrets[i].genCustomFields()
 		secrets[i].Name = secrets[i].Fullname // lastpass trims path from name, so we need to copy fullname
 	}
 	return secrets, nil
@@ -0,0 +1,114 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCLIBackendRead(t *testing.T) {
+	_, b := newFakeLpass(t, fakeState{LoggedIn: true, Secrets: []fakeSecret{
+		{ID: "1", Fullname: "Folder/multiline", Note: "ABC\nDEF"},
+		{ID: "2", Fullname: "singleline", Note: "ABC"},
+		{ID: "3", Fullname: "Servers/db", Note: "NoteType:Database\nHostname:db.example.com\nPort:5432\nNotes:line 1\nline 2"},
+	}})
+	client := Client{Backend: b}
+	secrets, err := client.Read("1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(secrets) != 1 {
+		t.Fatalf("expected 1 secret, got %d", len(secrets))
+	}
+	if secrets[0].Name != "Folder/multiline" || secrets[0].Group != "Folder" {
+		t.Errorf("expected full name and group, got %q and %q", secrets[0].Name, secrets[0].Group)
+	}
+	if secrets[0].Note != "ABC\nDEF\n" {
+		t.Errorf("expected trailing newline on multiline note, got %q", secrets[0].Note)
+	}
+	secrets, err = client.Read("2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if secrets[0].Note != "ABC" {
+		t.Errorf("single line note should be unchanged, got %q", secrets[0].Note)
+	}
+	secrets, err = client.Read("3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fields := secrets[0].CustomFields
+	if fields["Hostname"] != "db.example.com" || fields["Port"] != "5432" || fields["Notes"] != "line 1\nline 2\n" {
+		t.Errorf("unexpected custom fields %q", fields)
+	}
+}
+
+func TestCLIBackendReadNotFound(t *testing.T) {
+	ctx := context.Background()
+	_, b := newFakeLpass(t, fakeState{LoggedIn: true})
+	secrets, err := b.Read(ctx, "42")
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected not found error, got %v", err)
+	}
+	if len(secrets) != 0 {
+		t.Errorf("expected no secrets, got %+v", secrets)
+	}
+	var e *Error
+	if !errors.As(err, &e) || e.Message != "Error: Could not find specified account(s)." {
+		t.Errorf("expected lpass message in error, got %v", err)
+	}
+}
+
+func TestCLIBackendReadError(t *testing.T) {
+	ctx := context.Background()
+	_, b := newFakeLpass(t, fakeState{LoggedIn: true, Fail: map[string]string{"show": "Error: network down"}})
+	client := Client{Backend: b}
+	_, err := client.Read("42")
+	if err == nil || err.Error() != "Error: network down" {
+		t.Errorf("expected lpass stderr as error, got %v", err)
+	}
+	_, b = newFakeLpass(t, fakeState{LoggedIn: true, Output: map[string]string{"show": "not json"}})
+	_, err = b.Read(ctx, "42")
+	if err == nil {
+		t.Error("expected error on invalid lpass output")
+	}
+}
+
+func TestCLIBackendReadCancel(t *testing.T) {
+	_, b := newFakeLpass(t, fakeState{LoggedIn: true, Hang: map[string]bool{"show": true}})
+	client := Client{Backend: b}
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := client.ReadContext(ctx, "1")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline exceeded, got %v", err)
+	}
+	if time.Since(start) > 10*time.Second {
+		t.Error("hung lpass was not killed when ctx was done")
+	}
+}
+
+func TestClientReadExactID(t *testing.T) {
+	_, b := newFakeLpass(t, fakeState{LoggedIn: true, Secrets: []fakeSecret{
+		{ID: "123", Fullname: "db"},
+		{ID: "5", Fullname: "db-12"},
+		{ID: "120", Fullname: "Infra/", URL: "http://group"},
+	}})
+	client := Client{Backend: b}
+	// lpass show -G 12 matches all of them, none has the ID
+	if _, err := client.Read("12"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected not found error, got %v", err)
+	}
+	if err := client.DeleteFolder("12"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected not found error deleting a folder, got %v", err)
+	}
+	secrets, err := client.Read("5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(secrets) != 1 || secrets[0].Fullname != "db-12" {
+		t.Errorf("unexpected secrets %+v", secrets)
+	}
+}
@@ -0,0 +1,45 @@
+package api
+
+import (
+	"bytes"
+	"context"
+)
+
+// Rename moves a secret to another folder with lpass mv, and changes its
+// name with lpass edit --name.
+func (b *CLIBackend) Rename(ctx context.Context, id, fullname string) error {
+	secrets, err := b.Read(ctx, id)
+	if err != nil {
+		return err
+	}
+	var current *Secret
+	for i := range secrets {
+		if secrets[i].ID == id {
+			current = &secrets[i]
+		}
+	}
+	if current == nil {
+		return &Error{Err: ErrNotFound, Message: id}
+	}
+	oldGroup, oldName := splitName(current.Fullname)
+	group, name := splitName(fullname)
+	var errbuf bytes.Buffer
+	if group != oldGroup {
+		cmd := b.command(ctx, "mv", id, group)
+		cmd.Stderr = &errbuf
+		err = cmd.Run()
+		if err != nil {
+			return commandError(ctx, errbuf.String())
+		}
+	}
+	if name != oldName {
+		cmd := b.command(ctx, "edit", "--name", id, "--non-interactive", b.syncFlag(ctx))
+		cmd.Stdin = bytes.NewBufferString(fullname + "\n")
+		cmd.Stderr = &errbuf
+		err = cmd.Run()
+		if err != nil {
+			return commandError(ctx, errbuf.String())
+		}
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestCLIBackendRename(t *testing.T) {
+	ctx := context.Background()
+	f, b := newFakeLpass(t, fakeState{LoggedIn: true, Secrets: []fakeSecret{
+		{ID: "1", Fullname: "Infra/db", Password: "pw"},
+	}})
+	tests := []struct {
+		fullname string
+		calls    []string
+	}{
+		{"Apps/db", []string{"mv 1 Apps"}},
+		{"Apps/database", []string{"edit --name 1 --non-interactive --sync=now"}},
+		{"Infra/db", []string{"mv 1 Infra", "edit --name 1 --non-interactive --sync=now"}},
+		{"Infra/db", nil},
+	}
+	for _, tt := range tests {
+		before := len(f.state().Calls)
+		err := b.Rename(ctx, "1", tt.fullname)
+		if err != nil {
+			t.Fatal(err)
+		}
+		state := f.state()
+		var calls []string
+		for _, call := range state.Calls[before:] {
+			if call[:4] != "show" {
+				calls = append(calls, call)
+			}
+		}
+		if len(calls) != len(tt.calls) {
+			t.Errorf("Rename(%s): expected calls %q, got %q", tt.fullname, tt.calls, calls)
+		}
+		for i := range calls {
+			if i < len(tt.calls) && calls[i] != tt.calls[i] {
+				t.Errorf("Rename(%s): expected calls %q, got %q", tt.fullname, tt.calls, calls)
+			}
+		}
+		if s := state.Secrets[0]; s.Fullname != tt.fullname || s.Password != "pw" {
+			t.Errorf("Rename(%s): unexpected secret %+v", tt.fullname, s)
+		}
+	}
+	err := b.Rename(ctx, "2", "missing")
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected not found error, got %v", err)
+	}
+}
@@ -0,0 +1,88 @@
+package api
+
+import (
+	"context"
+	"math/rand"
+	"time"
+)
+
+// RetryPolicy controls how long Create keeps polling lpass for the ID of a
+// new secret. Zero durations fall back to DefaultRetryPolicy.
+type RetryPolicy struct {
+	// InitialDelay before the first attempt, doubled after each attempt.
+	InitialDelay time.Duration
+	// MaxDelay caps the delay between two attempts.
+	MaxDelay time.Duration
+	// Jitter randomizes each delay by up to this fraction, e.g. 0.2 = ±20%.
+	// Zero disables jitter.
+	Jitter float64
+	// Deadline is the total time to wait before giving up.
+	Deadline time.Duration
+	// Attempts, when set, also gives up after this many attempts.
+	Attempts int
+}
+
+// DefaultRetryPolicy is used for zero values in RetryPolicy.
+var DefaultRetryPolicy = RetryPolicy{
+	InitialDelay: time.Second,
+	MaxDelay:     8 * time.Second,
+	Jitter:       0.2,
+	Deadline:     time.Minute,
+}
+
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	if p.InitialDelay <= 0 {
+		p.InitialDelay = DefaultRetryPolicy.InitialDelay
+	}
+	if p.MaxDelay <= 0 {
+		p.MaxDelay = DefaultRetryPolicy.MaxDelay
+	}
+	if p.MaxDelay < p.InitialDelay {
+		p.MaxDelay = p.InitialDelay
+	}
+	if p.Jitter < 0 || p.Jitter > 1 {
+		p.Jitter = DefaultRetryPolicy.Jitter
+	}
+	if p.Deadline <= 0 {
+		p.Deadline = DefaultRetryPolicy.Deadline
+	}
+	return p
+}
+
+// delay returns how long to wait before the given attempt, counting from 0.
+func (p RetryPolicy) delay(attempt int) time.Duration {
+	d := p.InitialDelay
+	for i := 0; i < attempt && d < p.MaxDelay; i++ {
+		d *= 2
+	}
+	if d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+	if p.Jitter > 0 {
+		d += time.Duration(float64(d) * p.Jitter * (2*rand.Float64() - 1))
+	}
+	return d
+}
+
+// retry calls fn with backoff until it reports done, fails, the deadline
+// passes, it ran out of attempts or ctx is done. It returns false if it gave
+// up on the deadline or the attempts.
+func (p RetryPolicy) retry(ctx context.Context, fn func() (bool, error)) (bool, error) {
+	p = p.withDefaults()
+	deadline := time.Now().Add(p.Deadline)
+	for attempt := 0; ; attempt++ {
+		wait := p.delay(attempt)
+		if time.Now().Add(wait).After(deadline) || p.Attempts > 0 && attempt >= p.Attempts {
+			return false, nil
+		}
+		select {
+		case <-ctx.Done():
+			return false, ctx.Err()
+		case <-time.After(wait):
+		}
+		done, err := fn()
+		if done || err != nil {
+			return done, err
+		}
+	}
+}
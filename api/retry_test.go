@@ -0,0 +1,61 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicyDelay(t *testing.T) {
+	p := RetryPolicy{InitialDelay: time.Second, MaxDelay: 5 * time.Second}.withDefaults()
+	p.Jitter = 0
+	want := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}
+	for i, w := range want {
+		if d := p.delay(i); d != w {
+			t.Errorf("delay(%d) = %s, want %s", i, d, w)
+		}
+	}
+	p.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		if d := p.delay(0); d < 500*time.Millisecond || d > 1500*time.Millisecond {
+			t.Fatalf("delay with 50%% jitter out of range: %s", d)
+		}
+	}
+	if d := (RetryPolicy{Jitter: 0.2}).withDefaults(); d != DefaultRetryPolicy {
+		t.Errorf("expected defaults for zero policy, got %+v", d)
+	}
+}
+
+func TestRetryPolicyRetry(t *testing.T) {
+	ctx := context.Background()
+	p := RetryPolicy{InitialDelay: time.Millisecond, MaxDelay: time.Millisecond, Deadline: time.Second}
+	var calls int
+	done, err := p.retry(ctx, func() (bool, error) {
+		calls++
+		return calls == 3, nil
+	})
+	if !done || err != nil || calls != 3 {
+		t.Errorf("expected done after 3 calls, got %t, %v after %d calls", done, err, calls)
+	}
+	fail := errors.New("fail")
+	_, err = p.retry(ctx, func() (bool, error) { return false, fail })
+	if err != fail {
+		t.Errorf("expected error to stop retries, got %v", err)
+	}
+	p.Deadline = 20 * time.Millisecond
+	done, err = p.retry(ctx, func() (bool, error) { return false, nil })
+	if done || err != nil {
+		t.Errorf("expected to give up at the deadline, got %t, %v", done, err)
+	}
+	p.Deadline = time.Hour
+	p.Attempts = 4
+	calls = 0
+	done, err = p.retry(ctx, func() (bool, error) {
+		calls++
+		return false, nil
+	})
+	if done || err != nil || calls != 4 {
+		t.Errorf("expected to give up after 4 attempts, got %t, %v after %d calls", done, err, calls)
+	}
+}
@@ -0,0 +1,280 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"strings"
+)
+
+// ShareUser is a member of a shared folder.
+type ShareUser struct {
+	Username      string
+	ReadOnly      bool
+	Admin         bool
+	HidePasswords bool
+	// Accepted is false until the user accepts the invitation.
+	Accepted bool
+}
+
+// ShareBackend is implemented by backends able to manage shared folders.
+type ShareBackend interface {
+	CreateShare(ctx context.Context, name string) error
+	DeleteShare(ctx context.Context, name string) error
+	ShareUsers(ctx context.Context, name string) ([]ShareUser, error)
+	AddShareUser(ctx context.Context, name string, u ShareUser) error
+	UpdateShareUser(ctx context.Context, name string, u ShareUser) error
+	RemoveShareUser(ctx context.Context, name, username string) error
+}
+
+// shareName returns the shared folder a full name is in, if any.
+func shareName(fullname string) string {
+	share := strings.SplitN(fullname, "/", 2)[0]
+	if strings.HasPrefix(share, "Shared-") && share != fullname {
+		return share
+	}
+	return ""
+}
+
+// checkShareWrite makes sure we can add secrets to a shared folder, using
+// the secrets already in it or else its members.
+func (c *Client) checkShareWrite(ctx context.Context, share string) error {
+	all, err := c.cachedList(ctx)
+	if err != nil {
+		return err
+	}
+	for _, s := range all {
+		if s.Share != share || s.IsFolder() {
+			continue
+		}
+		secrets, err := c.readID(ctx, s.ID)
+		if errors.Is(err, ErrNotFound) {
+			// removed since we listed it
+			continue
+		} else if err != nil {
+			return err
+		}
+		if secrets[0].ShareReadonly {
+			return &Error{Err: ErrReadOnly, Message: share}
+		}
+		return nil
+	}
+	b, ok := c.backend().(ShareBackend)
+	if !ok {
+		return &Error{Err: ErrUnsupported, Message: "shared folders are only supported by the lpass backend"}
+	}
+	users, err := b.ShareUsers(ctx, share)
+	if errors.Is(err, ErrNotFound) {
+		return &Error{Err: ErrNotFound, Message: "shared folder " + share}
+	} else if errors.Is(err, ErrForbidden) {
+		// only admins can list members, leave it to Lastpass
+		log.Printf("[DEBUG] lastpass: can't list members of %s to check write access: %v", share, err)
+		return nil
+	} else if err != nil {
+		return err
+	}
+	for _, u := range users {
+		if strings.EqualFold(u.Username, c.Username) && u.ReadOnly {
+			return &Error{Err: ErrReadOnly, Message: share}
+		}
+	}
+	return nil
+}
+
+// shareBackend logs in and returns the backend if it supports shared folders.
+func (c *Client) shareBackend(ctx context.Context) (ShareBackend, error) {
+	b, ok := c.backend().(ShareBackend)
+	if !ok {
+		return nil, &Error{Err: ErrUnsupported, Message: "shared folders are only supported by the lpass backend"}
+	}
+	return b, c.login(ctx)
+}
+
+// CreateShare creates a shared folder, its name starts with "Shared-".
+func (c *Client) CreateShare(name string) error {
+	return c.CreateShareContext(context.Background(), name)
+}
+
+// CreateShareContext is like CreateShare, but gives up when ctx is done.
+func (c *Client) CreateShareContext(ctx context.Context, name string) error {
+	b, err := c.shareBackend(ctx)
+	if err != nil {
+		return err
+	}
+	// the new folder has no ID yet
+	defer c.invalidate()
+	return c.writeNow(ctx, "create share "+name, func(ctx context.Context) error {
+		return b.CreateShare(ctx, name)
+	})
+}
+
+// DeleteShare removes a shared folder and everything inside it.
+func (c *Client) DeleteShare(name string) error {
+	return c.DeleteShareContext(context.Background(), name)
+}
+
+// DeleteShareContext is like DeleteShare, but gives up when ctx is done.
+func (c *Client) DeleteShareContext(ctx context.Context, name string) error {
+	b, err := c.shareBackend(ctx)
+	if err != nil {
+		return err
+	}
+	// everything inside the folder goes with it
+	defer c.invalidate()
+	return c.writeNow(ctx, "delete share "+name, func(ctx context.Context) error {
+		return b.DeleteShare(ctx, name)
+	})
+}
+
+// ShareUsers lists the members of a shared folder, a missing shared folder
+// returns ErrNotFound.
+func (c *Client) ShareUsers(name string) ([]ShareUser, error) {
+	return c.ShareUsersContext(context.Background(), name)
+}
+
+// ShareUsersContext is like ShareUsers, but gives up when ctx is done.
+func (c *Client) ShareUsersContext(ctx context.Context, name string) ([]ShareUser, error) {
+	b, err := c.shareBackend(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return b.ShareUsers(ctx, name)
+}
+
+// AddShareUser invites a user to a shared folder.
+func (c *Client) AddShareUser(name string, u ShareUser) error {
+	return c.AddShareUserContext(context.Background(), name, u)
+}
+
+// AddShareUserContext is like AddShareUser, but gives up when ctx is done.
+func (c *Client) AddShareUserContext(ctx context.Context, name string, u ShareUser) error {
+	b, err := c.shareBackend(ctx)
+	if err != nil {
+		return err
+	}
+	return c.writeNow(ctx, "add share user "+u.Username, func(ctx context.Context) error {
+		return b.AddShareUser(ctx, name, u)
+	})
+}
+
+// UpdateShareUser changes the permissions of a member of a shared folder.
+func (c *Client) UpdateShareUser(name string, u ShareUser) error {
+	return c.UpdateShareUserContext(context.Background(), name, u)
+}
+
+// UpdateShareUserContext is like UpdateShareUser, but gives up when ctx is done.
+func (c *Client) UpdateShareUserContext(ctx context.Context, name string, u ShareUser) error {
+	b, err := c.shareBackend(ctx)
+	if err != nil {
+		return err
+	}
+	return c.writeNow(ctx, "update share user "+u.Username, func(ctx context.Context) error {
+		return b.UpdateShareUser(ctx, name, u)
+	})
+}
+
+// RemoveShareUser removes a member from a shared folder.
+func (c *Client) RemoveShareUser(name, username string) error {
+	return c.RemoveShareUserContext(context.Background(), name, username)
+}
+
+// RemoveShareUserContext is like RemoveShareUser, but gives up when ctx is done.
+func (c *Client) RemoveShareUserContext(ctx context.Context, name, username string) error {
+	b, err := c.shareBackend(ctx)
+	if err != nil {
+		return err
+	}
+	return c.writeNow(ctx, "remove share user "+username, func(ctx context.Context) error {
+		return b.RemoveShareUser(ctx, name, username)
+	})
+}
+
+// share runs a lpass share subcommand and returns its output.
+func (b *CLIBackend) share(ctx context.Context, args ...string) (string, error) {
+	var outbuf, errbuf bytes.Buffer
+	cmd := b.command(ctx, append([]string{"share"}, args...)...)
+	cmd.Stdout = &outbuf
+	cmd.Stderr = &errbuf
+	err := cmd.Run()
+	if err != nil {
+		return "", commandError(ctx, errbuf.String())
+	}
+	return outbuf.String(), nil
+}
+
+// CreateShare creates a shared folder with lpass share create.
+func (b *CLIBackend) CreateShare(ctx context.Context, name string) error {
+	_, err := b.share(ctx, "create", name)
+	return err
+}
+
+// DeleteShare removes a shared folder with lpass share rm.
+func (b *CLIBackend) DeleteShare(ctx context.Context, name string) error {
+	_, err := b.share(ctx, "rm", name)
+	return err
+}
+
+// ShareUsers lists the members of a shared folder with lpass share userls.
+func (b *CLIBackend) ShareUsers(ctx context.Context, name string) ([]ShareUser, error) {
+	out, err := b.share(ctx, "userls", name)
+	if err != nil {
+		return nil, err
+	}
+	return parseShareUsers(out), nil
+}
+
+// AddShareUser invites a user with lpass share useradd.
+func (b *CLIBackend) AddShareUser(ctx context.Context, name string, u ShareUser) error {
+	_, err := b.share(ctx, append(append([]string{"useradd"}, shareUserFlags(u)...), name, u.Username)...)
+	return err
+}
+
+// UpdateShareUser changes permissions with lpass share usermod.
+func (b *CLIBackend) UpdateShareUser(ctx context.Context, name string, u ShareUser) error {
+	_, err := b.share(ctx, append(append([]string{"usermod"}, shareUserFlags(u)...), name, u.Username)...)
+	return err
+}
+
+// RemoveShareUser removes a member with lpass share userdel.
+func (b *CLIBackend) RemoveShareUser(ctx context.Context, name, username string) error {
+	_, err := b.share(ctx, "userdel", name, username)
+	return err
+}
+
+func shareUserFlags(u ShareUser) []string {
+	flag := func(name string, v bool) string {
+		if v {
+			return "--" + name + "=true"
+		}
+		return "--" + name + "=false"
+	}
+	return []string{flag("read-only", u.ReadOnly), flag("hidden", u.HidePasswords), flag("admin", u.Admin)}
+}
+
+// parseShareUsers reads the table printed by lpass share userls:
+//
+//	User                                         RO  Admin   Hide OutEnt Accept
+//	Gopher <gopher@example.com>                   _      x      _      _      x
+func parseShareUsers(out string) []ShareUser {
+	var users []ShareUser
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 6 || fields[0] == "User" {
+			continue
+		}
+		flags := fields[len(fields)-5:]
+		name := strings.Join(fields[:len(fields)-5], " ")
+		if i := strings.LastIndex(name, "<"); i >= 0 && strings.HasSuffix(name, ">") {
+			name = name[i+1 : len(name)-1]
+		}
+		users = append(users, ShareUser{
+			Username:      name,
+			ReadOnly:      flags[0] == "x",
+			Admin:         flags[1] == "x",
+			HidePasswords: flags[2] == "x",
+			Accepted:      flags[4] == "x",
+		})
+	}
+	return users
+}
@@ -0,0 +1,147 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCLIBackendShare(t *testing.T) {
+	f, b := newFakeLpass(t, fakeState{LoggedIn: true, Username: "gopher@example.com"})
+	client := Client{Backend: b}
+	err := client.CreateShare("Shared-Infra")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = client.AddShareUser("Shared-Infra", ShareUser{Username: "ops@example.com", ReadOnly: true, HidePasswords: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	users, err := client.ShareUsers("Shared-Infra")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []ShareUser{
+		{Username: "gopher@example.com", Admin: true, Accepted: true},
+		{Username: "ops@example.com", ReadOnly: true, HidePasswords: true},
+	}
+	if len(users) != len(want) || users[0] != want[0] || users[1] != want[1] {
+		t.Errorf("expected %+v, got %+v", want, users)
+	}
+	err = client.UpdateShareUser("Shared-Infra", ShareUser{Username: "ops@example.com", Admin: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	state := f.state()
+	if call := state.Calls[len(state.Calls)-1]; call != "share usermod --read-only=false --hidden=false --admin=true Shared-Infra ops@example.com" {
+		t.Errorf("unexpected usermod call %q", call)
+	}
+	if u := state.Shares["Shared-Infra"][1]; !u.Admin || u.ReadOnly {
+		t.Errorf("user not updated: %+v", u)
+	}
+	err = client.RemoveShareUser("Shared-Infra", "ops@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = client.RemoveShareUser("Shared-Infra", "ops@example.com")
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected not found error for missing member, got %v", err)
+	}
+	err = client.DeleteShare("Shared-Infra")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = client.ShareUsers("Shared-Infra")
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected not found error for missing share, got %v", err)
+	}
+}
+
+func TestClientShareUnsupported(t *testing.T) {
+	client := Client{Backend: &NativeBackend{}}
+	_, err := client.ShareUsersContext(context.Background(), "Shared-Infra")
+	if !errors.Is(err, ErrUnsupported) {
+		t.Errorf("expected unsupported error, got %v", err)
+	}
+}
+
+func TestParseShareUsers(t *testing.T) {
+	out := `User                                         RO  Admin   Hide OutEnt Accept
+Gopher Gopherson <gopher@example.com>             _      x      _      _      x
+ops@example.com                                   x      _      x      _      _
+`
+	users := parseShareUsers(out)
+	want := []ShareUser{
+		{Username: "gopher@example.com", Admin: true, Accepted: true},
+		{Username: "ops@example.com", ReadOnly: true, HidePasswords: true},
+	}
+	if len(users) != 2 || users[0] != want[0] || users[1] != want[1] {
+		t.Errorf("expected %+v, got %+v", want, users)
+	}
+}
+
+func TestClientCreateInShare(t *testing.T) {
+	_, b := newFakeLpass(t, fakeState{LoggedIn: true, Username: "gopher@example.com",
+		Secrets: []fakeSecret{{ID: "1", Fullname: "Shared-RO/db", Share: "Shared-RO", ShareReadonly: true}},
+		Shares: map[string][]ShareUser{
+			"Shared-Empty": {{Username: "Gopher@example.com", ReadOnly: true}},
+			"Shared-Infra": {{Username: "gopher@example.com"}},
+		},
+	})
+	client := Client{Username: "gopher@example.com", Backend: b}
+	tests := []struct {
+		name string
+		err  error
+	}{
+		{"Shared-RO/web", ErrReadOnly},
+		{"Shared-Empty/web", ErrReadOnly},
+		{"Shared-Missing/web", ErrNotFound},
+		{"Shared-Infra/web", nil},
+	}
+	for _, tt := range tests {
+		_, err := client.Create(Secret{Name: tt.name})
+		if !errors.Is(err, tt.err) {
+			t.Errorf("Create(%s): expected error %v, got %v", tt.name, tt.err, err)
+		}
+	}
+	secrets, err := client.Read("1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if secrets[0].Share != "Shared-RO" || !secrets[0].ShareReadonly {
+		t.Errorf("expected read-only share, got %+v", secrets[0])
+	}
+	created, _ := client.List(ListFilter{Share: "Shared-Infra"})
+	if len(created) != 1 || created[0].Fullname != "Shared-Infra/web" {
+		t.Errorf("expected secret created in shared folder, got %+v", created)
+	}
+	_, err = (&Client{Backend: &MemoryBackend{}}).Create(Secret{Name: "Shared-Infra/web"})
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected missing shared folder error, got %v", err)
+	}
+}
+
+func TestClientCreateInShareMembers(t *testing.T) {
+	tests := []struct {
+		fail string
+		ok   bool
+	}{
+		// members can't list the other members, Lastpass decides
+		{"Error: Unable to access user list for share Shared-Infra", true},
+		{"Error: Could not find decryption key. Perhaps you need to login with `lpass login`.", false},
+		{"Error: Server error", false},
+	}
+	for _, tt := range tests {
+		_, b := newFakeLpass(t, fakeState{LoggedIn: true, Username: "gopher@example.com",
+			Fail: map[string]string{"share": tt.fail},
+		})
+		client := Client{Username: "gopher@example.com", Backend: b}
+		_, err := client.Create(Secret{Name: "Shared-Infra/web"})
+		if tt.ok && err != nil {
+			t.Errorf("%s: unexpected error %v", tt.fail, err)
+		} else if !tt.ok && (err == nil || !strings.Contains(err.Error(), tt.fail)) {
+			t.Errorf("%s: expected the error to be returned, got %v", tt.fail, err)
+		}
+	}
+}
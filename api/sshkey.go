@@ -0,0 +1,173 @@
+package api
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/binary"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// SSH key algorithms supported by GenerateSSHKey.
+const (
+	SSHKeyED25519 = "ED25519"
+	SSHKeyRSA     = "RSA"
+	SSHKeyECDSA   = "ECDSA"
+)
+
+// SSHKey is a keypair stored in an "SSH Key" secure note.
+type SSHKey struct {
+	// PrivateKey is PEM encoded, in the OpenSSH format for ED25519 keys.
+	PrivateKey string
+	// PublicKey is in authorized_keys format, without a comment.
+	PublicKey   string
+	Fingerprint string
+	Algorithm   string
+	Bits        int
+}
+
+// GenerateSSHKey creates a keypair using crypto/rand. Bits is the RSA key
+// size or ECDSA curve size, 0 picks 4096 for RSA and 256 for ECDSA. ED25519
+// keys are always 256 bits.
+func GenerateSSHKey(algorithm string, bits int) (SSHKey, error) {
+	var raw interface{}
+	var block *pem.Block
+	switch algorithm {
+	case SSHKeyED25519:
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return SSHKey{}, err
+		}
+		block, err = marshalED25519(key)
+		if err != nil {
+			return SSHKey{}, err
+		}
+		raw = key
+	case SSHKeyRSA:
+		if bits == 0 {
+			bits = 4096
+		}
+		if bits < 2048 {
+			return SSHKey{}, fmt.Errorf("RSA keys need at least 2048 bits, got %d", bits)
+		}
+		key, err := rsa.GenerateKey(rand.Reader, bits)
+		if err != nil {
+			return SSHKey{}, err
+		}
+		raw, block = key, &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}
+	case SSHKeyECDSA:
+		var curve elliptic.Curve
+		switch bits {
+		case 0, 256:
+			curve = elliptic.P256()
+		case 384:
+			curve = elliptic.P384()
+		case 521:
+			curve = elliptic.P521()
+		default:
+			return SSHKey{}, fmt.Errorf("ECDSA keys are 256, 384 or 521 bits, got %d", bits)
+		}
+		key, err := ecdsa.GenerateKey(curve, rand.Reader)
+		if err != nil {
+			return SSHKey{}, err
+		}
+		der, err := x509.MarshalECPrivateKey(key)
+		if err != nil {
+			return SSHKey{}, err
+		}
+		raw, block = key, &pem.Block{Type: "EC PRIVATE KEY", Bytes: der}
+	default:
+		return SSHKey{}, fmt.Errorf("unsupported SSH key algorithm %q", algorithm)
+	}
+	key, err := sshKey(raw)
+	key.PrivateKey = string(pem.EncodeToMemory(block))
+	return key, err
+}
+
+// ParseSSHKey reads a PEM encoded private key, encrypted keys need the
+// passphrase.
+func ParseSSHKey(privateKey, passphrase string) (SSHKey, error) {
+	var raw interface{}
+	var err error
+	if passphrase != "" {
+		raw, err = ssh.ParseRawPrivateKeyWithPassphrase([]byte(privateKey), []byte(passphrase))
+	} else {
+		raw, err = ssh.ParseRawPrivateKey([]byte(privateKey))
+	}
+	var missing *ssh.PassphraseMissingError
+	if errors.As(err, &missing) {
+		return SSHKey{}, errors.New("the private key is encrypted, a passphrase is required")
+	} else if err != nil {
+		return SSHKey{}, err
+	}
+	key, err := sshKey(raw)
+	key.PrivateKey = privateKey
+	return key, err
+}
+
+// sshKey fills in the public parts of a key from the private key.
+func sshKey(raw interface{}) (SSHKey, error) {
+	var key SSHKey
+	switch k := raw.(type) {
+	case ed25519.PrivateKey:
+		key.Algorithm, key.Bits = SSHKeyED25519, 256
+	case *ed25519.PrivateKey:
+		key.Algorithm, key.Bits = SSHKeyED25519, 256
+	case *rsa.PrivateKey:
+		key.Algorithm, key.Bits = SSHKeyRSA, k.N.BitLen()
+	case *ecdsa.PrivateKey:
+		key.Algorithm, key.Bits = SSHKeyECDSA, k.Curve.Params().BitSize
+	default:
+		return key, fmt.Errorf("unsupported SSH key type %T", raw)
+	}
+	signer, err := ssh.NewSignerFromKey(raw)
+	if err != nil {
+		return key, err
+	}
+	key.PublicKey = strings.TrimSuffix(string(ssh.MarshalAuthorizedKey(signer.PublicKey())), "\n")
+	key.Fingerprint = ssh.FingerprintSHA256(signer.PublicKey())
+	return key, nil
+}
+
+// marshalED25519 encodes an unencrypted key in the openssh-key-v1 format,
+// the only format OpenSSH reads ED25519 keys in.
+func marshalED25519(key ed25519.PrivateKey) (*pem.Block, error) {
+	pub := ssh.Marshal(struct {
+		Type string
+		Key  []byte
+	}{ssh.KeyAlgoED25519, key.Public().(ed25519.PublicKey)})
+	var check [4]byte
+	if _, err := rand.Read(check[:]); err != nil {
+		return nil, err
+	}
+	priv := ssh.Marshal(struct {
+		Check1, Check2 uint32
+		Type           string
+		Pub, Priv      []byte
+		Comment        string
+	}{
+		Check1: binary.BigEndian.Uint32(check[:]),
+		Check2: binary.BigEndian.Uint32(check[:]),
+		Type:   ssh.KeyAlgoED25519,
+		Pub:    key.Public().(ed25519.PublicKey),
+		Priv:   key,
+	})
+	// pad to the block size of the "none" cipher
+	for i := 1; len(priv)%8 != 0; i++ {
+		priv = append(priv, byte(i))
+	}
+	data := ssh.Marshal(struct {
+		Cipher, KDF, KDFOptions string
+		Keys                    uint32
+		Pub, Priv               []byte
+	}{"none", "none", "", 1, pub, priv})
+	return &pem.Block{Type: "OPENSSH PRIVATE KEY", Bytes: append([]byte("openssh-key-v1\x00"), data...)}, nil
+}
@@ -0,0 +1,102 @@
+package api
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGenerateSSHKey(t *testing.T) {
+	for _, tc := range []struct {
+		algorithm string
+		bits      int
+		wantBits  int
+		prefix    string
+	}{
+		{SSHKeyED25519, 0, 256, "ssh-ed25519 "},
+		{SSHKeyRSA, 2048, 2048, "ssh-rsa "},
+		{SSHKeyECDSA, 0, 256, "ecdsa-sha2-nistp256 "},
+		{SSHKeyECDSA, 384, 384, "ecdsa-sha2-nistp384 "},
+	} {
+		key, err := GenerateSSHKey(tc.algorithm, tc.bits)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.algorithm, err)
+		}
+		if key.Bits != tc.wantBits || !strings.HasPrefix(key.PublicKey, tc.prefix) || !strings.HasPrefix(key.Fingerprint, "SHA256:") {
+			t.Errorf("%s: unexpected key %+v", tc.algorithm, key)
+		}
+		// the private key has to be readable by ssh
+		parsed, err := ParseSSHKey(key.PrivateKey, "")
+		if err != nil {
+			t.Fatalf("%s: %v", tc.algorithm, err)
+		}
+		if parsed != key {
+			t.Errorf("%s: parsed key %+v, want %+v", tc.algorithm, parsed, key)
+		}
+	}
+	if _, err := GenerateSSHKey(SSHKeyRSA, 1024); err == nil {
+		t.Error("expected an error for a short RSA key")
+	}
+	if _, err := GenerateSSHKey("DSA", 0); err == nil {
+		t.Error("expected an error for an unsupported algorithm")
+	}
+}
+
+func TestParseSSHKeyInvalid(t *testing.T) {
+	if _, err := ParseSSHKey("not a key", ""); err == nil {
+		t.Error("expected an error")
+	}
+}
+
+func TestSSHKeyNoteRoundTrip(t *testing.T) {
+	key, err := GenerateSSHKey(SSHKeyED25519, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &Client{Backend: &MemoryBackend{}}
+	s, err := client.Create(Secret{
+		Name:     "deploy",
+		NoteType: "SSH Key",
+		Note:     "hello",
+		CustomFields: map[string]string{
+			"Private Key": key.PrivateKey,
+			"Public Key":  key.PublicKey,
+			"Hostname":    "example.com",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	secrets, err := client.Read(s.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := secrets[0].CustomFields
+	if f["Private Key"] != key.PrivateKey || f["Public Key"] != key.PublicKey || f["Hostname"] != "example.com" || f["Notes"] != "hello" {
+		t.Errorf("multi-line fields did not round-trip: %q", f)
+	}
+}
+
+func TestCLIBackendSSHKeyNote(t *testing.T) {
+	_, b := newFakeLpass(t, fakeState{LoggedIn: true})
+	client := Client{Backend: b}
+	key, err := GenerateSSHKey(SSHKeyRSA, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := client.Create(Secret{
+		Name:         "deploy",
+		NoteType:     "SSH Key",
+		CustomFields: map[string]string{"Private Key": key.PrivateKey, "Public Key": key.PublicKey, "Hostname": "example.com"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	secrets, err := client.Read(s.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := secrets[0].CustomFields
+	if strings.TrimSpace(f["Private Key"]) != strings.TrimSpace(key.PrivateKey) || f["Public Key"] != key.PublicKey || f["Hostname"] != "example.com" {
+		t.Errorf("multi-line fields did not round-trip: %q", f)
+	}
+}
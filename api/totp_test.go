@@ -0,0 +1,35 @@
+package api
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTOTP(t *testing.T) {
+	// test vectors from RFC 6238, truncated to six digits
+	secret := "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+	tests := map[int64]string{
+		59:          "287082",
+		1111111109:  "081804",
+		1234567890:  "005924",
+		20000000000: "353130",
+	}
+	for ts, want := range tests {
+		got, err := TOTP(secret, time.Unix(ts, 0))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("TOTP at %d = %s, want %s", ts, got, want)
+		}
+	}
+	// seeds are often shown lower case in groups of four
+	got, _ := TOTP("gezd gnbv gy3t qojq gezd gnbv gy3t qojq", time.Unix(59, 0))
+	if got != "287082" {
+		t.Errorf("expected spaces and case to be ignored, got %s", got)
+	}
+	_, err := TOTP("not base32!", time.Now())
+	if err == nil {
+		t.Error("expected error on invalid secret")
+	}
+}
@@ -2,26 +2,23 @@ package api
 
 import (
 	"bytes"
-	"errors"
-	"os/exec"
+	"context"
 )
 
-// Update is called to update secret with upstream
-func (c *Client) Update(s Secret) error {
-	err := c.login()
+// Update edits an existing secret with lpass edit.
+func (b *CLIBackend) Update(ctx context.Context, s Secret) error {
+	template, err := s.getTemplate()
 	if err != nil {
 		return err
 	}
-	template := s.getTemplate()
-	cmd := exec.Command("lpass", "edit", s.ID, "--non-interactive", "--sync=now")
+	cmd := b.command(ctx, "edit", s.ID, "--non-interactive", b.syncFlag(ctx))
 	var inbuf, errbuf bytes.Buffer
 	inbuf.Write([]byte(template))
 	cmd.Stdin = &inbuf
 	cmd.Stderr = &errbuf
 	err = cmd.Run()
 	if err != nil {
-		var err = errors.New(errbuf.String())
-		return err
+		return commandError(ctx, errbuf.String())
 	}
 	return nil
 }
@@ -0,0 +1,41 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestCLIBackendUpdate(t *testing.T) {
+	ctx := context.Background()
+	f, b := newFakeLpass(t, fakeState{LoggedIn: true, Secrets: []fakeSecret{
+		{ID: "1", Fullname: "mysecret", Username: "user", Password: "pw"},
+	}})
+	err := b.Update(ctx, Secret{ID: "1", Name: "mysecret", Username: "user2", Password: "pw2", Note: "123\n456\n"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := f.state().Secrets[0]
+	if s.Username != "user2" || s.Password != "pw2" || s.Note != "123\n456" {
+		t.Errorf("Update() not applied: %+v", s)
+	}
+	err = b.Update(ctx, Secret{ID: "2", Name: "missing"})
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected not found error, got %v", err)
+	}
+}
+
+func TestCLIBackendUpdateSecureNote(t *testing.T) {
+	ctx := context.Background()
+	f, b := newFakeLpass(t, fakeState{LoggedIn: true, Secrets: []fakeSecret{
+		{ID: "1", Fullname: "mydb", URL: "http://sn", Note: "NoteType:Database\nLanguage:en-US\nHostname:old\nNotes:"},
+	}})
+	err := b.Update(ctx, Secret{ID: "1", Name: "mydb", NoteType: "Database", CustomFields: map[string]string{"Hostname": "new"}, Note: "hi"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "NoteType:Database\nLanguage:en-US\nHostname:new\nNotes:hi"
+	if s := f.state().Secrets[0]; s.Note != want {
+		t.Errorf("expected note %q, got %q", want, s.Note)
+	}
+}
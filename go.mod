@@ -2,4 +2,8 @@ module github.com/nrkno/terraform-provider-lastpass
 
 go 1.16
 
-require github.com/hashicorp/terraform-plugin-sdk/v2 v2.7.0
+require (
+	github.com/hashicorp/go-cty v1.4.1-0.20200414143053-d3edf31b6320
+	github.com/hashicorp/terraform-plugin-sdk/v2 v2.7.0
+	golang.org/x/crypto v0.0.0-20210421170649-83a5a9bb288b
+)
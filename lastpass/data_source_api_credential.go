@@ -0,0 +1,72 @@
+package lastpass
+
+import (
+	"context"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/nrkno/terraform-provider-lastpass/api"
+)
+
+// DataSourceAPICredential describes our lastpass API credential data source
+func DataSourceAPICredential() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: DataSourceAPICredentialRead,
+		Timeouts: &schema.ResourceTimeout{
+			Read: schema.DefaultTimeout(2 * time.Minute),
+		},
+		Schema: map[string]*schema.Schema{
+			"id": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				ExactlyOneOf: []string{"id", "fullname"},
+			},
+			"fullname": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				ExactlyOneOf: []string{"id", "fullname"},
+			},
+			"endpoint": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"key_id": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"secret": {
+				Type:      schema.TypeString,
+				Computed:  true,
+				Sensitive: true,
+			},
+			"expires": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"note": {
+				Type:      schema.TypeString,
+				Computed:  true,
+				Sensitive: true,
+			},
+		},
+	}
+}
+
+// DataSourceAPICredentialRead reads an API credential note from upstream/lastpass
+func DataSourceAPICredentialRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	s, diags := dataSourceNote(ctx, d, m.(*api.Client))
+	if diags.HasError() {
+		return diags
+	}
+	c, err := api.APICredentialFromSecret(s)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	d.SetId(s.ID)
+	d.Set("fullname", s.Fullname)
+	setAPICredential(d, c)
+	return nil
+}
@@ -0,0 +1,88 @@
+package lastpass
+
+import (
+	"context"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/nrkno/terraform-provider-lastpass/api"
+)
+
+// DataSourceDatabaseCredential describes our lastpass database credential data source
+func DataSourceDatabaseCredential() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: DataSourceDatabaseCredentialRead,
+		Timeouts: &schema.ResourceTimeout{
+			Read: schema.DefaultTimeout(2 * time.Minute),
+		},
+		Schema: map[string]*schema.Schema{
+			"id": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				ExactlyOneOf: []string{"id", "fullname"},
+			},
+			"fullname": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				ExactlyOneOf: []string{"id", "fullname"},
+			},
+			"type": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"hostname": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"port": {
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+			"database": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"username": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"password": {
+				Type:      schema.TypeString,
+				Computed:  true,
+				Sensitive: true,
+			},
+			"sid": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"alias": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"note": {
+				Type:      schema.TypeString,
+				Computed:  true,
+				Sensitive: true,
+			},
+		},
+	}
+}
+
+// DataSourceDatabaseCredentialRead reads a Database note from upstream/lastpass
+func DataSourceDatabaseCredentialRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	s, diags := dataSourceNote(ctx, d, m.(*api.Client))
+	if diags.HasError() {
+		return diags
+	}
+	c, err := api.DatabaseCredentialFromSecret(s)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	d.SetId(s.ID)
+	d.Set("fullname", s.Fullname)
+	setDatabaseCredential(d, c)
+	return nil
+}
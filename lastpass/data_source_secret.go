@@ -2,11 +2,15 @@ package lastpass
 
 import (
 	"context"
+	"encoding/base64"
 	"errors"
 	"strconv"
+	"time"
 
+	"github.com/hashicorp/go-cty/cty"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 	"github.com/nrkno/terraform-provider-lastpass/api"
 )
 
@@ -14,22 +18,34 @@ import (
 func DataSourceSecret() *schema.Resource {
 	return &schema.Resource{
 		ReadContext: DataSourceSecretRead,
+		Timeouts: &schema.ResourceTimeout{
+			Read: schema.DefaultTimeout(2 * time.Minute),
+		},
 		Schema: map[string]*schema.Schema{
 			"id": {
-				Type:     schema.TypeString,
-				Required: true,
+				Type:          schema.TypeString,
+				Optional:      true,
+				Computed:      true,
+				AtLeastOneOf:  dataSourceSecretSelectors,
+				ConflictsWith: []string{"name", "fullname", "url", "username"},
 			},
 			"name": {
-				Type:     schema.TypeString,
-				Computed: true,
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				AtLeastOneOf: dataSourceSecretSelectors,
 			},
 			"fullname": {
-				Type:     schema.TypeString,
-				Computed: true,
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				AtLeastOneOf: dataSourceSecretSelectors,
 			},
 			"username": {
-				Type:     schema.TypeString,
-				Computed: true,
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				AtLeastOneOf: dataSourceSecretSelectors,
 			},
 			"password": {
 				Type:      schema.TypeString,
@@ -44,58 +60,228 @@ func DataSourceSecret() *schema.Resource {
 				Type:     schema.TypeString,
 				Computed: true,
 			},
+			"last_modified": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "RFC3339 time of the last change.",
+			},
+			"last_touched": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "RFC3339 time the secret was last used.",
+			},
+			"password_last_changed": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "RFC3339 time the password last changed.",
+			},
+			"password_age_days": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "Days since the password last changed.",
+			},
+			"share": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The shared folder the secret is in.",
+			},
+			"share_readonly": {
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
+			"is_shared": {
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
 			"group": {
 				Type:     schema.TypeString,
 				Computed: true,
 			},
 			"url": {
-				Type:     schema.TypeString,
-				Computed: true,
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				AtLeastOneOf: dataSourceSecretSelectors,
+			},
+			"match": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "exact",
+				ValidateFunc: validation.StringInSlice([]string{"exact", "regex"}, false),
+				Description:  "How name, fullname, url and username are matched.",
 			},
 			"note": {
 				Type:      schema.TypeString,
 				Computed:  true,
 				Sensitive: true,
 			},
+			"note_type": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
 			"custom_fields": {
 				Type:      schema.TypeMap,
 				Computed:  true,
 				Sensitive: true,
 			},
+			"attachments": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"id": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"name": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"size": {
+							Type:     schema.TypeInt,
+							Computed: true,
+						},
+						"content_base64": {
+							Type:      schema.TypeString,
+							Computed:  true,
+							Sensitive: true,
+						},
+					},
+				},
+			},
 		},
 	}
 }
 
+// dataSourceSecretSelectors are the arguments a secret can be looked up by.
+var dataSourceSecretSelectors = []string{"id", "name", "fullname", "url", "username"}
+
 // DataSourceSecretRead reads resource from upstream/lastpass
 func DataSourceSecretRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
 	client := m.(*api.Client)
 	var diags diag.Diagnostics
 	id := d.Get("id").(string)
+	if id == "" {
+		return dataSourceSecretFind(ctx, d, client)
+	}
 	if _, err := strconv.Atoi(id); err != nil {
-		err := errors.New("Not a valid Lastpass ID")
-		return diag.FromErr(err)
+		return diag.Diagnostics{{
+			Severity:      diag.Error,
+			Summary:       "Not a valid Lastpass ID",
+			Detail:        "Lastpass IDs are numerical, got " + id,
+			AttributePath: cty.GetAttrPath("id"),
+		}}
 	}
-	secrets, err := client.Read(id)
+	secrets, err := client.ReadContext(ctx, id)
 	if err != nil {
-		return diag.FromErr(err)
+		return errorDiags(err)
 	}
-	if len(secrets) == 0 {
-		d.SetId("")
-		return diags
-	} else if len(secrets) > 1 {
+	if len(secrets) > 1 {
 		var err = errors.New("got duplicate IDs")
 		return diag.FromErr(err)
 	}
-	d.SetId(secrets[0].ID)
 	d.Set("name", secrets[0].Name)
 	d.Set("fullname", secrets[0].Fullname)
 	d.Set("username", secrets[0].Username)
-	d.Set("password", secrets[0].Password)
-	d.Set("last_modified_gmt", secrets[0].LastModifiedGmt)
-	d.Set("last_touch", secrets[0].LastTouch)
-	d.Set("group", secrets[0].Group)
 	d.Set("url", secrets[0].URL)
-	d.Set("note", secrets[0].Note)
-	d.Set("custom_fields", secrets[0].CustomFields)
+	setDataSourceSecret(d, secrets[0])
+	return append(diags, setDataSourceAttachments(ctx, d, client)...)
+}
+
+// dataSourceSecretFind looks up the secret by name, fullname, url and username.
+func dataSourceSecretFind(ctx context.Context, d *schema.ResourceData, client *api.Client) diag.Diagnostics {
+	sel := api.Selector{
+		Name:     d.Get("name").(string),
+		Fullname: d.Get("fullname").(string),
+		URL:      d.Get("url").(string),
+		Username: d.Get("username").(string),
+		Regex:    d.Get("match").(string) == "regex",
+	}
+	secret, err := client.FindContext(ctx, sel)
+	var e *api.Error
+	if errors.Is(err, api.ErrNotFound) {
+		return diag.Diagnostics{{
+			Severity: diag.Error,
+			Summary:  "No secret matches",
+			Detail:   "Lastpass has no secret matching " + sel.String() + ".",
+		}}
+	} else if errors.As(err, &e) && errors.Is(err, api.ErrAmbiguousName) {
+		return diag.Diagnostics{{
+			Severity: diag.Error,
+			Summary:  "More than one secret matches",
+			Detail:   "Narrow down the lookup, or use id instead. " + e.Message,
+		}}
+	} else if err != nil {
+		return errorDiags(err)
+	}
+	// keep the selectors as given, only fill in the ones not used
+	for k, v := range map[string]string{"name": secret.Name, "fullname": secret.Fullname, "username": secret.Username, "url": secret.URL} {
+		if d.Get(k).(string) == "" {
+			d.Set(k, v)
+		}
+	}
+	setDataSourceSecret(d, secret)
+	return setDataSourceAttachments(ctx, d, client)
+}
+
+// setDataSourceSecret sets the attributes of the data source that are never
+// used to look up the secret.
+func setDataSourceSecret(d *schema.ResourceData, s api.Secret) {
+	d.SetId(s.ID)
+	d.Set("password", s.Password)
+	d.Set("last_modified_gmt", s.LastModifiedGmt)
+	d.Set("last_touch", s.LastTouch)
+	setSecretTimes(d, s)
+	d.Set("group", s.Group)
+	d.Set("share", s.Share)
+	d.Set("share_readonly", s.ShareReadonly)
+	d.Set("is_shared", s.Share != "")
+	d.Set("note", s.Note)
+	d.Set("note_type", s.NoteType)
+	d.Set("custom_fields", s.CustomFields)
+}
+
+// setDataSourceAttachments reads the attachments of the secret, backends
+// without attachment support give an empty list and a warning.
+func setDataSourceAttachments(ctx context.Context, d *schema.ResourceData, client *api.Client) diag.Diagnostics {
+	var diags diag.Diagnostics
+	attachments, err := client.AttachmentsContext(ctx, d.Id())
+	if errors.Is(err, api.ErrUnsupported) {
+		diags = append(diags, diag.Diagnostic{
+			Severity:      diag.Warning,
+			Summary:       "Attachments not read",
+			Detail:        "The backend can't read attachments, attachments is empty even if the secret has some. Use the lpass backend to read them. " + err.Error(),
+			AttributePath: cty.GetAttrPath("attachments"),
+		})
+	} else if err != nil {
+		return errorDiags(err)
+	}
+	var list []map[string]interface{}
+	for _, a := range attachments {
+		list = append(list, map[string]interface{}{
+			"id":             a.ID,
+			"name":           a.Filename,
+			"size":           len(a.Content),
+			"content_base64": base64.StdEncoding.EncodeToString(a.Content),
+		})
+	}
+	d.Set("attachments", list)
 	return diags
 }
+
+// dataSourceNote reads the secret picked by the id or fullname arguments of
+// the typed note data sources.
+func dataSourceNote(ctx context.Context, d *schema.ResourceData, client *api.Client) (api.Secret, diag.Diagnostics) {
+	if id := d.Get("id").(string); id != "" {
+		secrets, err := client.ReadContext(ctx, id)
+		if err != nil {
+			return api.Secret{}, errorDiags(err)
+		}
+		return secrets[0], nil
+	}
+	s, err := client.FindContext(ctx, api.Selector{Fullname: d.Get("fullname").(string)})
+	if err != nil {
+		return s, errorDiags(err)
+	}
+	return s, nil
+}
@@ -1,11 +1,60 @@
 package lastpass
 
 import (
+	"context"
 	"testing"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/nrkno/terraform-provider-lastpass/api"
 )
 
+func TestDataSourceSecretFind(t *testing.T) {
+	ctx := context.Background()
+	client := &api.Client{Backend: &api.MemoryBackend{}}
+	for _, s := range []api.Secret{
+		{Name: "Infra/db", Username: "admin", Password: "pw", URL: "https://db.example.com"},
+		{Name: "Apps/db", Username: "app", URL: "https://db.example.com"},
+	} {
+		if _, err := client.Create(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tests := []struct {
+		raw     map[string]interface{}
+		id      string
+		summary string
+	}{
+		{map[string]interface{}{"fullname": "Infra/db"}, "1", ""},
+		{map[string]interface{}{"name": "db", "username": "app"}, "2", ""},
+		{map[string]interface{}{"fullname": "^Infra/", "match": "regex"}, "1", ""},
+		{map[string]interface{}{"url": "https://db.example.com"}, "", "More than one secret matches"},
+		{map[string]interface{}{"name": "web"}, "", "No secret matches"},
+	}
+	for _, tt := range tests {
+		d := schema.TestResourceDataRaw(t, DataSourceSecret().Schema, tt.raw)
+		diags := DataSourceSecretRead(ctx, d, client)
+		if tt.summary != "" {
+			if len(diags) != 1 || diags[0].Summary != tt.summary {
+				t.Errorf("%v: expected %q, got %v", tt.raw, tt.summary, diags)
+			}
+			continue
+		}
+		if diags.HasError() {
+			t.Errorf("%v: unexpected diagnostics: %v", tt.raw, diags)
+			continue
+		}
+		if d.Id() != tt.id {
+			t.Errorf("%v: expected ID %q, got %q", tt.raw, tt.id, d.Id())
+		}
+	}
+	d := schema.TestResourceDataRaw(t, DataSourceSecret().Schema, map[string]interface{}{"fullname": "Infra/db"})
+	DataSourceSecretRead(ctx, d, client)
+	if d.Get("password") != "pw" || d.Get("username") != "admin" || d.Get("name") != "Infra/db" {
+		t.Errorf("attributes not set from the matching secret: %v", d.State().Attributes)
+	}
+}
+
 func TestAccDataSourceSecret_Basic(t *testing.T) {
 	resource.Test(t, resource.TestCase{
 		PreCheck:  func() { testAccPreCheck(t) },
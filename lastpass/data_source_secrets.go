@@ -0,0 +1,142 @@
+package lastpass
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/nrkno/terraform-provider-lastpass/api"
+)
+
+// DataSourceSecrets describes our data source listing lastpass secrets
+func DataSourceSecrets() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: DataSourceSecretsRead,
+		Timeouts: &schema.ResourceTimeout{
+			Read: schema.DefaultTimeout(5 * time.Minute),
+		},
+		Schema: map[string]*schema.Schema{
+			"folder": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Only list secrets in this folder or its subfolders.",
+			},
+			"name_regex": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.StringIsValidRegExp,
+				Description:  "Only list secrets with a full name matching this regular expression.",
+			},
+			"share": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Only list secrets in this shared folder.",
+			},
+			"note_type": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.StringInSlice(api.NoteTypeNames(), false),
+				Description:  "Only list secure notes of this template.",
+			},
+			"modified_after": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.IsRFC3339Time,
+				Description:  "Only list secrets modified after this RFC3339 time.",
+			},
+			"modified_before": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.IsRFC3339Time,
+				Description:  "Only list secrets modified before this RFC3339 time.",
+			},
+			"ids": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+			"secrets": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"id": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"name": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"fullname": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"group": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"share": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"username": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"url": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// DataSourceSecretsRead lists the secrets matching the filters.
+func DataSourceSecretsRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	client := m.(*api.Client)
+	filter := api.ListFilter{
+		Folder:   d.Get("folder").(string),
+		Name:     d.Get("name_regex").(string),
+		Share:    d.Get("share").(string),
+		NoteType: d.Get("note_type").(string),
+	}
+	// validated by the schema
+	if v, ok := d.GetOk("modified_after"); ok {
+		filter.ModifiedAfter, _ = time.Parse(time.RFC3339, v.(string))
+	}
+	if v, ok := d.GetOk("modified_before"); ok {
+		filter.ModifiedBefore, _ = time.Parse(time.RFC3339, v.(string))
+	}
+	secrets, err := client.ListContext(ctx, filter)
+	if err != nil {
+		return errorDiags(err)
+	}
+	ids := make([]string, 0, len(secrets))
+	list := make([]map[string]interface{}, 0, len(secrets))
+	for _, s := range secrets {
+		ids = append(ids, s.ID)
+		list = append(list, map[string]interface{}{
+			"id":       s.ID,
+			"name":     s.BaseName(),
+			"fullname": s.Fullname,
+			"group":    s.Group,
+			"share":    s.Share,
+			"username": s.Username,
+			"url":      s.URL,
+		})
+	}
+	sum := sha256.Sum256([]byte(strings.Join(ids, ",")))
+	d.SetId(hex.EncodeToString(sum[:]))
+	d.Set("ids", ids)
+	d.Set("secrets", list)
+	return nil
+}
@@ -0,0 +1,54 @@
+package lastpass
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/nrkno/terraform-provider-lastpass/api"
+)
+
+func TestDataSourceSecretsRead(t *testing.T) {
+	ctx := context.Background()
+	client := &api.Client{Backend: &api.MemoryBackend{}}
+	for _, s := range []api.Secret{
+		{Name: "Infra/db"},
+		{Name: "Infra/Servers/web", NoteType: "Server"},
+		{Name: "Apps/web"},
+	} {
+		if _, err := client.Create(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tests := []struct {
+		raw map[string]interface{}
+		ids []string
+	}{
+		{map[string]interface{}{}, []string{"1", "2", "3"}},
+		{map[string]interface{}{"folder": "Infra"}, []string{"1", "2"}},
+		{map[string]interface{}{"name_regex": "web$", "note_type": "Server"}, []string{"2"}},
+		{map[string]interface{}{"modified_before": "2021-01-01T00:00:00Z"}, []string{}},
+	}
+	for _, tt := range tests {
+		d := schema.TestResourceDataRaw(t, DataSourceSecrets().Schema, tt.raw)
+		diags := DataSourceSecretsRead(ctx, d, client)
+		if diags.HasError() {
+			t.Fatalf("unexpected diagnostics: %v", diags)
+		}
+		ids := d.Get("ids").([]interface{})
+		if len(ids) != len(tt.ids) {
+			t.Errorf("%v: expected %v, got %v", tt.raw, tt.ids, ids)
+			continue
+		}
+		for i := range ids {
+			if ids[i] != tt.ids[i] {
+				t.Errorf("%v: expected %v, got %v", tt.raw, tt.ids, ids)
+			}
+		}
+	}
+	d := schema.TestResourceDataRaw(t, DataSourceSecrets().Schema, map[string]interface{}{"folder": "Infra/Servers"})
+	DataSourceSecretsRead(ctx, d, client)
+	if d.Get("secrets.0.name") != "web" || d.Get("secrets.0.fullname") != "Infra/Servers/web" || d.Get("secrets.0.group") != "Infra/Servers" {
+		t.Errorf("unexpected secrets: %v", d.Get("secrets"))
+	}
+}
@@ -0,0 +1,68 @@
+package lastpass
+
+import (
+	"context"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/nrkno/terraform-provider-lastpass/api"
+)
+
+// DataSourceServerCredential describes our lastpass server credential data source
+func DataSourceServerCredential() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: DataSourceServerCredentialRead,
+		Timeouts: &schema.ResourceTimeout{
+			Read: schema.DefaultTimeout(2 * time.Minute),
+		},
+		Schema: map[string]*schema.Schema{
+			"id": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				ExactlyOneOf: []string{"id", "fullname"},
+			},
+			"fullname": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				ExactlyOneOf: []string{"id", "fullname"},
+			},
+			"hostname": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"username": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"password": {
+				Type:      schema.TypeString,
+				Computed:  true,
+				Sensitive: true,
+			},
+			"note": {
+				Type:      schema.TypeString,
+				Computed:  true,
+				Sensitive: true,
+			},
+		},
+	}
+}
+
+// DataSourceServerCredentialRead reads a Server note from upstream/lastpass
+func DataSourceServerCredentialRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	s, diags := dataSourceNote(ctx, d, m.(*api.Client))
+	if diags.HasError() {
+		return diags
+	}
+	c, err := api.ServerCredentialFromSecret(s)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	d.SetId(s.ID)
+	d.Set("fullname", s.Fullname)
+	setServerCredential(d, c)
+	return nil
+}
@@ -0,0 +1,69 @@
+package lastpass
+
+import (
+	"context"
+	"errors"
+
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/nrkno/terraform-provider-lastpass/api"
+)
+
+// errorDiags turns an api error into a diagnostic with a readable summary,
+// the message from Lastpass as detail, and the attribute it relates to.
+func errorDiags(err error) diag.Diagnostics {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return diag.Diagnostics{{
+			Severity: diag.Error,
+			Summary:  "Timed out talking to Lastpass",
+			Detail:   "The operation did not finish in time, consider raising the timeouts of the resource.",
+		}}
+	}
+	var e *api.Error
+	if !errors.As(err, &e) || e.Err == nil {
+		return diag.FromErr(err)
+	}
+	d := diag.Diagnostic{
+		Severity: diag.Error,
+		Summary:  e.Err.Error(),
+		Detail:   e.Message,
+	}
+	switch {
+	case errors.Is(err, api.ErrNotFound):
+		d.Summary = "Secret not found in Lastpass"
+		d.AttributePath = cty.GetAttrPath("id")
+	case errors.Is(err, api.ErrAmbiguousName):
+		d.Summary = "Secret name is not unique"
+		d.Detail = "Another secret with the same name exists, unable to tell them apart. " + e.Message
+		d.AttributePath = cty.GetAttrPath("name")
+	case errors.Is(err, api.ErrSyncTimeout):
+		d.Summary = "Timed out waiting for Lastpass to sync"
+		d.Detail = "The secret may still have been created, check Lastpass and import it if so. " + e.Message
+	case errors.Is(err, api.ErrNotLoggedIn):
+		d.Summary = "Not logged in to Lastpass"
+		d.Detail = "Set username and password on the provider, or login manually with lpass. " + e.Message
+	case errors.Is(err, api.ErrMFARequired):
+		d.Summary = "MFA required"
+		d.Detail = "Lastpass requires a second factor, set otp, totp_secret or out_of_band on the provider. " + e.Message
+	case errors.Is(err, api.ErrInvalidOTP):
+		d.Summary = "Invalid OTP"
+		d.Detail = "Lastpass rejected the multifactor code. " + e.Message
+	case errors.Is(err, api.ErrAuthFailed):
+		d.Summary = "Lastpass login failed"
+	case errors.Is(err, api.ErrNotEmpty):
+		d.Summary = "Folder not empty"
+		d.Detail = "Only empty folders can be deleted, move or delete the secrets inside first. " + e.Message
+	case errors.Is(err, api.ErrReadOnly):
+		d.Summary = "Shared folder is read-only"
+		d.Detail = "You do not have write access to the shared folder, ask an admin of it for access. " + e.Message
+	case errors.Is(err, api.ErrForbidden):
+		d.Summary = "Not allowed by Lastpass"
+		d.Detail = "Your account lacks the permission needed, e.g. to manage a shared folder you are not an admin of. " + e.Message
+	case errors.Is(err, api.ErrUnsupported):
+		d.Summary = "Not supported by the backend"
+	case errors.Is(err, api.ErrRateLimited):
+		d.Summary = "Rate limited by Lastpass"
+		d.Detail = "Try again later, or lower -parallelism. " + e.Message
+	}
+	return diag.Diagnostics{d}
+}
@@ -0,0 +1,62 @@
+package lastpass
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/nrkno/terraform-provider-lastpass/api"
+)
+
+func TestErrorDiags(t *testing.T) {
+	tests := []struct {
+		err     error
+		summary string
+		path    cty.Path
+	}{
+		{&api.Error{Err: api.ErrNotFound, Message: "42"}, "Secret not found in Lastpass", cty.GetAttrPath("id")},
+		{&api.Error{Err: api.ErrAmbiguousName}, "Secret name is not unique", cty.GetAttrPath("name")},
+		{&api.Error{Err: api.ErrMFARequired}, "MFA required", nil},
+		{&api.Error{Err: api.ErrInvalidOTP}, "Invalid OTP", nil},
+		{&api.Error{Err: api.ErrRateLimited}, "Rate limited by Lastpass", nil},
+		{&api.Error{Err: api.ErrNotEmpty}, "Folder not empty", nil},
+		{&api.Error{Err: api.ErrUnsupported}, "Not supported by the backend", nil},
+		{&api.Error{Err: api.ErrReadOnly}, "Shared folder is read-only", nil},
+		{&api.Error{Err: api.ErrForbidden}, "Not allowed by Lastpass", nil},
+		{&api.Error{Message: "Error: unknown"}, "Error: unknown", nil},
+		{errors.New("plain error"), "plain error", nil},
+		{context.DeadlineExceeded, "Timed out talking to Lastpass", nil},
+	}
+	for _, tt := range tests {
+		diags := errorDiags(tt.err)
+		if len(diags) != 1 {
+			t.Fatalf("expected one diagnostic, got %d", len(diags))
+		}
+		if diags[0].Summary != tt.summary {
+			t.Errorf("expected summary %q, got %q", tt.summary, diags[0].Summary)
+		}
+		if !diags[0].AttributePath.Equals(tt.path) {
+			t.Errorf("expected path %#v for %q, got %#v", tt.path, tt.summary, diags[0].AttributePath)
+		}
+	}
+}
+
+func TestResourceSecretReadRemoved(t *testing.T) {
+	client := &api.Client{Backend: &api.MemoryBackend{}}
+	d := schema.TestResourceDataRaw(t, ResourceSecret().Schema, map[string]interface{}{"name": "gone"})
+	d.SetId("42")
+	diags := ResourceSecretRead(context.Background(), d, client)
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+	if d.Id() != "" {
+		t.Error("secret removed outside of Terraform should be removed from state")
+	}
+	d.SetId("42")
+	diags = ResourceSecretDelete(context.Background(), d, client)
+	if diags.HasError() {
+		t.Errorf("deleting an already removed secret should succeed: %v", diags)
+	}
+}
@@ -0,0 +1,33 @@
+package lastpass
+
+import (
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/nrkno/terraform-provider-lastpass/api"
+)
+
+// setSecretTimes sets the RFC3339 timestamps of a secret and the age of its
+// password. The password attributes are left unset, and so null, when the
+// backend doesn't know when the password changed; an age of 0 would make a
+// stale password look brand new.
+func setSecretTimes(d *schema.ResourceData, s api.Secret) {
+	modified, _ := s.LastModified()
+	touched, _ := s.LastTouched()
+	d.Set("last_modified", formatTime(modified))
+	d.Set("last_touched", formatTime(touched))
+	changed, err := s.PasswordChanged()
+	if err != nil || changed.IsZero() {
+		return
+	}
+	d.Set("password_last_changed", formatTime(changed))
+	d.Set("password_age_days", int(time.Since(changed)/(24*time.Hour)))
+}
+
+// formatTime formats t as RFC3339, the zero time as an empty string.
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
@@ -2,9 +2,13 @@ package lastpass
 
 import (
 	"context"
+	"fmt"
+	"strconv"
+	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 	"github.com/nrkno/terraform-provider-lastpass/api"
 )
 
@@ -18,10 +22,22 @@ type config struct {
 func Provider() *schema.Provider {
 	return &schema.Provider{
 		ResourcesMap: map[string]*schema.Resource{
-			"lastpass_secret": ResourceSecret(),
+			"lastpass_secret":               ResourceSecret(),
+			"lastpass_folder":               ResourceFolder(),
+			"lastpass_shared_folder":        ResourceSharedFolder(),
+			"lastpass_shared_folder_member": ResourceSharedFolderMember(),
+			"lastpass_secret_attachment":    ResourceSecretAttachment(),
+			"lastpass_ssh_key":              ResourceSSHKey(),
+			"lastpass_database_credential":  ResourceDatabaseCredential(),
+			"lastpass_server_credential":    ResourceServerCredential(),
+			"lastpass_api_credential":       ResourceAPICredential(),
 		},
 		DataSourcesMap: map[string]*schema.Resource{
-			"lastpass_secret": DataSourceSecret(),
+			"lastpass_secret":              DataSourceSecret(),
+			"lastpass_secrets":             DataSourceSecrets(),
+			"lastpass_database_credential": DataSourceDatabaseCredential(),
+			"lastpass_server_credential":   DataSourceServerCredential(),
+			"lastpass_api_credential":      DataSourceAPICredential(),
 		},
 		Schema: map[string]*schema.Schema{
 			"username": {
@@ -37,6 +53,109 @@ func Provider() *schema.Provider {
 				Description: "Lastpass login password",
 				DefaultFunc: schema.EnvDefaultFunc("LASTPASS_PASSWORD", nil),
 			},
+			"otp": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				Sensitive:     true,
+				Description:   "One-time multifactor code used when logging in",
+				DefaultFunc:   schema.EnvDefaultFunc("LASTPASS_OTP", nil),
+				ConflictsWith: []string{"totp_secret"},
+			},
+			"totp_secret": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				Sensitive:     true,
+				Description:   "Base32 TOTP seed, used to generate the multifactor code when logging in",
+				DefaultFunc:   schema.EnvDefaultFunc("LASTPASS_TOTP_SECRET", nil),
+				ConflictsWith: []string{"otp"},
+			},
+			"out_of_band": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Description: "Wait for the login to be approved out-of-band, e.g. with a Duo or Lastpass Authenticator push",
+			},
+			"backend": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Description:  "Backend used to talk to Lastpass",
+				DefaultFunc:  schema.EnvDefaultFunc("LASTPASS_BACKEND", "lpass"),
+				ValidateFunc: validation.StringInSlice([]string{"lpass", "native"}, false),
+			},
+			"lpass_path": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Path to the lpass binary, defaults to lpass from $PATH",
+			},
+			"lpass_home": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Directory where lpass keeps its session (LPASS_HOME), defaults to a directory per username",
+			},
+			"agent_timeout": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Description:  "Seconds before the lpass agent logs out (LPASS_AGENT_TIMEOUT), 0 never logs out",
+				ValidateFunc: validation.IntAtLeast(0),
+			},
+			"sync_retry": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				MaxItems:    1,
+				Description: "How lpass is polled for the ID of newly created secrets",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"initial_delay": {
+							Type:         schema.TypeString,
+							Optional:     true,
+							Default:      "1s",
+							Description:  "Delay before the first attempt, doubled after each attempt",
+							ValidateFunc: validateDuration,
+						},
+						"max_delay": {
+							Type:         schema.TypeString,
+							Optional:     true,
+							Default:      "8s",
+							Description:  "Maximum delay between two attempts",
+							ValidateFunc: validateDuration,
+						},
+						"jitter": {
+							Type:         schema.TypeFloat,
+							Optional:     true,
+							Default:      0.2,
+							Description:  "Randomize each delay by up to this fraction",
+							ValidateFunc: validation.FloatBetween(0, 1),
+						},
+						"timeout": {
+							Type:         schema.TypeString,
+							Optional:     true,
+							Default:      "1m",
+							Description:  "Total time to wait before giving up",
+							ValidateFunc: validateDuration,
+						},
+					},
+				},
+			},
+			"read_password_changes": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "With the lpass backend, also log in to the Lastpass API to read when passwords last changed",
+			},
+			"max_concurrent_writes": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      1,
+				Description:  "How many changes are sent to Lastpass at once, the rest wait in a queue",
+				ValidateFunc: validation.IntAtLeast(1),
+			},
+			"env": {
+				Type:        schema.TypeMap,
+				Optional:    true,
+				Description: "Extra environment variables passed to lpass",
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
 		},
 		ConfigureContextFunc: providerConfigure,
 	}
@@ -45,8 +164,68 @@ func Provider() *schema.Provider {
 func providerConfigure(ctx context.Context, d *schema.ResourceData) (interface{}, diag.Diagnostics) {
 	var diags diag.Diagnostics
 	client := api.Client{
-		Username: d.Get("username").(string),
-		Password: d.Get("password").(string),
+		Username:   d.Get("username").(string),
+		Password:   d.Get("password").(string),
+		OTP:        d.Get("otp").(string),
+		TOTPSecret: d.Get("totp_secret").(string),
+		OutOfBand:  d.Get("out_of_band").(bool),
+		MaxWrites:  d.Get("max_concurrent_writes").(int),
+	}
+	switch d.Get("backend").(string) {
+	case "lpass":
+		backend := &api.CLIBackend{
+			Path: d.Get("lpass_path").(string),
+			Home: d.Get("lpass_home").(string),
+			Env:  make(map[string]string),
+		}
+		for k, v := range d.Get("env").(map[string]interface{}) {
+			backend.Env[k] = v.(string)
+		}
+		if home, ok := backend.Env["LPASS_HOME"]; ok {
+			if backend.Home != "" {
+				return nil, diag.Errorf("lpass_home and env.LPASS_HOME are both set, use lpass_home")
+			}
+			delete(backend.Env, "LPASS_HOME")
+			backend.Home = home
+		}
+		if backend.Home == "" && client.Username != "" {
+			// keep one isolated lpass session per account
+			home, err := api.SessionDir(client.Username)
+			if err != nil {
+				return nil, diag.FromErr(err)
+			}
+			backend.Home = home
+		}
+		if v, ok := d.GetOkExists("agent_timeout"); ok {
+			backend.Env["LPASS_AGENT_TIMEOUT"] = strconv.Itoa(v.(int))
+		}
+		if d.Get("read_password_changes").(bool) {
+			if client.Username == "" || client.Password == "" {
+				return nil, diag.Errorf("read_password_changes requires username and password")
+			}
+			backend.PasswordChanges = &api.NativeBackend{}
+		}
+		backend.Retry = api.DefaultRetryPolicy
+		if v, ok := d.GetOk("sync_retry"); ok && v.([]interface{})[0] != nil {
+			retry := v.([]interface{})[0].(map[string]interface{})
+			// durations are already validated
+			backend.Retry.InitialDelay, _ = time.ParseDuration(retry["initial_delay"].(string))
+			backend.Retry.MaxDelay, _ = time.ParseDuration(retry["max_delay"].(string))
+			backend.Retry.Jitter = retry["jitter"].(float64)
+			backend.Retry.Deadline, _ = time.ParseDuration(retry["timeout"].(string))
+		}
+		client.Backend = backend
+	case "native":
+		client.Backend = &api.NativeBackend{}
+	default:
+		return nil, diag.Errorf("unknown backend %q", d.Get("backend").(string))
 	}
 	return &client, diags
 }
+
+func validateDuration(v interface{}, k string) ([]string, []error) {
+	if _, err := time.ParseDuration(v.(string)); err != nil {
+		return nil, []error{fmt.Errorf("%q must be a duration like \"2s\": %s", k, err)}
+	}
+	return nil, nil
+}
@@ -1,10 +1,13 @@
 package lastpass
 
 import (
+	"context"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/nrkno/terraform-provider-lastpass/api"
 )
 
 var testAccProviders map[string]*schema.Provider
@@ -23,6 +26,112 @@ func TestProvider(t *testing.T) {
 	}
 }
 
+func TestProviderConfigure(t *testing.T) {
+	d := schema.TestResourceDataRaw(t, Provider().Schema, map[string]interface{}{
+		"username":      "gopher@example.com",
+		"password":      "hunter2",
+		"lpass_path":    "/opt/lpass/bin/lpass",
+		"lpass_home":    "/tmp/lpass",
+		"agent_timeout": 0,
+		"env": map[string]interface{}{
+			"LPASS_CLIPBOARD_COMMAND": "cat",
+		},
+		"sync_retry": []interface{}{
+			map[string]interface{}{
+				"initial_delay": "500ms",
+				"timeout":       "2m",
+			},
+		},
+	})
+	m, diags := providerConfigure(context.Background(), d)
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+	client := m.(*api.Client)
+	backend, ok := client.Backend.(*api.CLIBackend)
+	if !ok {
+		t.Fatalf("expected lpass backend, got %T", client.Backend)
+	}
+	if backend.Path != "/opt/lpass/bin/lpass" || backend.Home != "/tmp/lpass" {
+		t.Errorf("unexpected backend %+v", backend)
+	}
+	if backend.Env["LPASS_AGENT_TIMEOUT"] != "0" || backend.Env["LPASS_CLIPBOARD_COMMAND"] != "cat" {
+		t.Errorf("unexpected backend env %v", backend.Env)
+	}
+	want := api.RetryPolicy{InitialDelay: 500 * time.Millisecond, MaxDelay: 8 * time.Second, Jitter: 0.2, Deadline: 2 * time.Minute}
+	if backend.Retry != want {
+		t.Errorf("unexpected retry policy %+v", backend.Retry)
+	}
+}
+
+func TestProviderConfigureSessionDir(t *testing.T) {
+	home := func(username string) string {
+		d := schema.TestResourceDataRaw(t, Provider().Schema, map[string]interface{}{
+			"username": username,
+			"password": "hunter2",
+		})
+		m, diags := providerConfigure(context.Background(), d)
+		if diags.HasError() {
+			t.Fatalf("unexpected diagnostics: %v", diags)
+		}
+		return m.(*api.Client).Backend.(*api.CLIBackend).Home
+	}
+	a, b := home("a@example.com"), home("b@example.com")
+	if a == "" || a == b {
+		t.Errorf("expected separate lpass homes per account, got %q and %q", a, b)
+	}
+	if manual := home(""); manual != "" {
+		t.Errorf("manual login should use the default lpass home, got %q", manual)
+	}
+}
+
+func TestProviderConfigurePasswordChanges(t *testing.T) {
+	d := schema.TestResourceDataRaw(t, Provider().Schema, map[string]interface{}{
+		"username":              "gopher@example.com",
+		"password":              "hunter2",
+		"read_password_changes": true,
+	})
+	m, diags := providerConfigure(context.Background(), d)
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+	if m.(*api.Client).Backend.(*api.CLIBackend).PasswordChanges == nil {
+		t.Error("expected password changes to be read from the Lastpass API")
+	}
+	d = schema.TestResourceDataRaw(t, Provider().Schema, map[string]interface{}{
+		"password":              "",
+		"read_password_changes": true,
+	})
+	if _, diags := providerConfigure(context.Background(), d); !diags.HasError() {
+		t.Error("expected an error reading password changes without credentials")
+	}
+}
+
+func TestProviderConfigureEnvHome(t *testing.T) {
+	d := schema.TestResourceDataRaw(t, Provider().Schema, map[string]interface{}{
+		"username": "gopher@example.com",
+		"password": "hunter2",
+		"env":      map[string]interface{}{"LPASS_HOME": "/tmp/lpass-env"},
+	})
+	m, diags := providerConfigure(context.Background(), d)
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+	backend := m.(*api.Client).Backend.(*api.CLIBackend)
+	if _, ok := backend.Env["LPASS_HOME"]; backend.Home != "/tmp/lpass-env" || ok {
+		t.Errorf("expected env.LPASS_HOME to be used as the lpass home, got %+v", backend)
+	}
+	d = schema.TestResourceDataRaw(t, Provider().Schema, map[string]interface{}{
+		"username":   "gopher@example.com",
+		"password":   "hunter2",
+		"lpass_home": "/tmp/lpass",
+		"env":        map[string]interface{}{"LPASS_HOME": "/tmp/lpass-env"},
+	})
+	if _, diags := providerConfigure(context.Background(), d); !diags.HasError() {
+		t.Error("expected an error setting both lpass_home and env.LPASS_HOME")
+	}
+}
+
 func testAccPreCheck(t *testing.T) {
 	if v := os.Getenv("LASTPASS_USER"); v == "" {
 		t.Fatal("LASTPASS_USER must be set for acceptance tests")
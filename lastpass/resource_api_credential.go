@@ -0,0 +1,164 @@
+package lastpass
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/nrkno/terraform-provider-lastpass/api"
+)
+
+// ResourceAPICredential describes our lastpass API credential resource
+func ResourceAPICredential() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: ResourceAPICredentialCreate,
+		ReadContext:   ResourceAPICredentialRead,
+		UpdateContext: ResourceAPICredentialUpdate,
+		DeleteContext: ResourceSecretDelete,
+		Importer: &schema.ResourceImporter{
+			StateContext: ResourceAPICredentialImporter,
+		},
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(5 * time.Minute),
+			Read:   schema.DefaultTimeout(2 * time.Minute),
+			Update: schema.DefaultTimeout(2 * time.Minute),
+			Delete: schema.DefaultTimeout(2 * time.Minute),
+		},
+
+		Schema: map[string]*schema.Schema{
+			"name": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"folder": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Folder of the credential, name is relative to it.",
+			},
+			"fullname": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"endpoint": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "URL of the API.",
+			},
+			"key_id": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"secret": {
+				Type:      schema.TypeString,
+				Optional:  true,
+				Sensitive: true,
+			},
+			"expires": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "When the key expires, free text.",
+			},
+			"note": {
+				Type:      schema.TypeString,
+				Optional:  true,
+				Sensitive: true,
+			},
+		},
+	}
+}
+
+func resourceAPICredential(d *schema.ResourceData) (api.Secret, error) {
+	c := api.APICredential{
+		Endpoint:  d.Get("endpoint").(string),
+		KeyID:     d.Get("key_id").(string),
+		SecretKey: d.Get("secret").(string),
+		Expires:   d.Get("expires").(string),
+		Note:      d.Get("note").(string),
+	}
+	return c.Secret(secretFullname(d.Get("folder").(string), d.Get("name").(string)))
+}
+
+// setAPICredential sets the fields of a credential, shared with the data
+// source.
+func setAPICredential(d *schema.ResourceData, c api.APICredential) {
+	d.Set("endpoint", c.Endpoint)
+	d.Set("key_id", c.KeyID)
+	d.Set("secret", c.SecretKey)
+	d.Set("expires", c.Expires)
+	d.Set("note", c.Note)
+}
+
+// ResourceAPICredentialCreate stores the credential as a secure note.
+func ResourceAPICredentialCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	client := m.(*api.Client)
+	s, err := resourceAPICredential(d)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	s, err = client.CreateContext(ctx, s)
+	if err != nil {
+		return errorDiags(err)
+	}
+	d.SetId(s.ID)
+	return ResourceAPICredentialRead(ctx, d, m)
+}
+
+// ResourceAPICredentialRead is used to sync the local state with the actual state (upstream/lastpass)
+func ResourceAPICredentialRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	client := m.(*api.Client)
+	secrets, err := client.ReadContext(ctx, d.Id())
+	if errors.Is(err, api.ErrNotFound) {
+		// removed outside of Terraform
+		d.SetId("")
+		return nil
+	} else if err != nil {
+		return errorDiags(err)
+	}
+	c, err := api.APICredentialFromSecret(secrets[0])
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	setSecretName(d, secrets[0])
+	setAPICredential(d, c)
+	return nil
+}
+
+// ResourceAPICredentialUpdate is used to update our existing resource
+func ResourceAPICredentialUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	client := m.(*api.Client)
+	s, err := resourceAPICredential(d)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	s.ID = d.Id()
+	if d.HasChanges("name", "folder") {
+		// move in place, keeping the ID
+		err := client.RenameContext(ctx, s.ID, s.Name)
+		if err != nil {
+			return errorDiags(err)
+		}
+	}
+	err = client.UpdateContext(ctx, s)
+	if err != nil {
+		return errorDiags(err)
+	}
+	return ResourceAPICredentialRead(ctx, d, m)
+}
+
+// ResourceAPICredentialImporter is called to import an existing API credential note.
+func ResourceAPICredentialImporter(ctx context.Context, d *schema.ResourceData, m interface{}) ([]*schema.ResourceData, error) {
+	if _, err := strconv.Atoi(d.Id()); err != nil {
+		return nil, errors.New("Not a valid Lastpass ID")
+	}
+	diags := ResourceAPICredentialRead(ctx, d, m)
+	if diags.HasError() {
+		return nil, errors.New(diags[0].Summary)
+	}
+	if d.Id() == "" {
+		return nil, errors.New("API credential not found")
+	}
+	return []*schema.ResourceData{d}, nil
+}
@@ -0,0 +1,101 @@
+package lastpass
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/nrkno/terraform-provider-lastpass/api"
+)
+
+func TestResourceAPICredential(t *testing.T) {
+	ctx := context.Background()
+	client := &api.Client{Backend: &api.MemoryBackend{}}
+	d := schema.TestResourceDataRaw(t, ResourceAPICredential().Schema, map[string]interface{}{
+		"name":     "billing",
+		"endpoint": "https://api.example.com",
+		"key_id":   "AKIA",
+		"secret":   "hunter2",
+		"note":     "rotated by hand",
+	})
+	diags := ResourceAPICredentialCreate(ctx, d, client)
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+	id := d.Id()
+	secrets, err := client.Read(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := secrets[0]; s.NoteType != "API Credential" || s.CustomFields["Key ID"] != "AKIA" || s.CustomFields["Notes"] != "rotated by hand" {
+		t.Errorf("unexpected API credential note: %+v", s)
+	}
+	d.Set("folder", "APIs")
+	d.Set("expires", "2027-01-01")
+	diags = ResourceAPICredentialUpdate(ctx, d, client)
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+	if d.Id() != id || d.Get("fullname") != "APIs/billing" || d.Get("expires") != "2027-01-01" || d.Get("secret") != "hunter2" {
+		t.Errorf("credential not updated in place: %v", d.State().Attributes)
+	}
+	ds := schema.TestResourceDataRaw(t, DataSourceAPICredential().Schema, map[string]interface{}{"fullname": "APIs/billing"})
+	diags = DataSourceAPICredentialRead(ctx, ds, client)
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+	if ds.Id() != id || ds.Get("endpoint") != "https://api.example.com" || ds.Get("key_id") != "AKIA" || ds.Get("secret") != "hunter2" {
+		t.Errorf("unexpected data source state: %v", ds.State().Attributes)
+	}
+	// an API credential is not a server
+	srv := schema.TestResourceDataRaw(t, DataSourceServerCredential().Schema, map[string]interface{}{"id": id})
+	diags = DataSourceServerCredentialRead(ctx, srv, client)
+	if !diags.HasError() {
+		t.Error("expected an error reading an API credential as a server credential")
+	}
+}
+
+func TestResourceAPICredentialImporter(t *testing.T) {
+	ctx := context.Background()
+	client := &api.Client{Backend: &api.MemoryBackend{}}
+	srv, err := client.Create(api.ServerCredential{Hostname: "web"}.Secret("web"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := api.APICredential{KeyID: "AKIA"}.Secret("billing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err = client.Create(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := schema.TestResourceDataRaw(t, ResourceAPICredential().Schema, map[string]interface{}{})
+	d.SetId(s.ID)
+	if _, err := ResourceAPICredentialImporter(ctx, d, client); err != nil {
+		t.Fatal(err)
+	}
+	if d.Get("name") != "billing" || d.Get("key_id") != "AKIA" {
+		t.Errorf("unexpected imported state: %v", d.State().Attributes)
+	}
+	for _, id := range []string{"not-a-number", srv.ID, "42"} {
+		d := schema.TestResourceDataRaw(t, ResourceAPICredential().Schema, map[string]interface{}{})
+		d.SetId(id)
+		if _, err := ResourceAPICredentialImporter(ctx, d, client); err == nil {
+			t.Errorf("expected an error importing %s", id)
+		}
+	}
+}
+
+func TestResourceAPICredentialInvalidField(t *testing.T) {
+	ctx := context.Background()
+	client := &api.Client{Backend: &api.MemoryBackend{}}
+	d := schema.TestResourceDataRaw(t, ResourceAPICredential().Schema, map[string]interface{}{
+		"name":   "billing",
+		"secret": "line 1\nKey ID:x",
+	})
+	diags := ResourceAPICredentialCreate(ctx, d, client)
+	if !diags.HasError() {
+		t.Error("expected an error for a secret that would start another field")
+	}
+}
@@ -0,0 +1,183 @@
+package lastpass
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/nrkno/terraform-provider-lastpass/api"
+)
+
+// ResourceDatabaseCredential describes our lastpass database credential resource
+func ResourceDatabaseCredential() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: ResourceDatabaseCredentialCreate,
+		ReadContext:   ResourceDatabaseCredentialRead,
+		UpdateContext: ResourceDatabaseCredentialUpdate,
+		DeleteContext: ResourceSecretDelete,
+		Importer: &schema.ResourceImporter{
+			StateContext: ResourceDatabaseCredentialImporter,
+		},
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(5 * time.Minute),
+			Read:   schema.DefaultTimeout(2 * time.Minute),
+			Update: schema.DefaultTimeout(2 * time.Minute),
+			Delete: schema.DefaultTimeout(2 * time.Minute),
+		},
+
+		Schema: map[string]*schema.Schema{
+			"name": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"folder": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Folder of the credential, name is relative to it.",
+			},
+			"fullname": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"type": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Kind of database, e.g. PostgreSQL.",
+			},
+			"hostname": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"port": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				ValidateFunc: validation.IsPortNumber,
+			},
+			"database": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"username": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"password": {
+				Type:      schema.TypeString,
+				Optional:  true,
+				Sensitive: true,
+			},
+			"sid": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Oracle system identifier.",
+			},
+			"alias": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"note": {
+				Type:      schema.TypeString,
+				Optional:  true,
+				Sensitive: true,
+			},
+		},
+	}
+}
+
+func resourceDatabaseCredential(d *schema.ResourceData) api.Secret {
+	c := api.DatabaseCredential{
+		Type:     d.Get("type").(string),
+		Hostname: d.Get("hostname").(string),
+		Port:     d.Get("port").(int),
+		Database: d.Get("database").(string),
+		Username: d.Get("username").(string),
+		Password: d.Get("password").(string),
+		SID:      d.Get("sid").(string),
+		Alias:    d.Get("alias").(string),
+		Note:     d.Get("note").(string),
+	}
+	return c.Secret(secretFullname(d.Get("folder").(string), d.Get("name").(string)))
+}
+
+// setDatabaseCredential sets the fields of a credential, shared with the
+// data source.
+func setDatabaseCredential(d *schema.ResourceData, c api.DatabaseCredential) {
+	d.Set("type", c.Type)
+	d.Set("hostname", c.Hostname)
+	d.Set("port", c.Port)
+	d.Set("database", c.Database)
+	d.Set("username", c.Username)
+	d.Set("password", c.Password)
+	d.Set("sid", c.SID)
+	d.Set("alias", c.Alias)
+	d.Set("note", c.Note)
+}
+
+// ResourceDatabaseCredentialCreate stores the credential as a Database note.
+func ResourceDatabaseCredentialCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	client := m.(*api.Client)
+	s, err := client.CreateContext(ctx, resourceDatabaseCredential(d))
+	if err != nil {
+		return errorDiags(err)
+	}
+	d.SetId(s.ID)
+	return ResourceDatabaseCredentialRead(ctx, d, m)
+}
+
+// ResourceDatabaseCredentialRead is used to sync the local state with the actual state (upstream/lastpass)
+func ResourceDatabaseCredentialRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	client := m.(*api.Client)
+	secrets, err := client.ReadContext(ctx, d.Id())
+	if errors.Is(err, api.ErrNotFound) {
+		// removed outside of Terraform
+		d.SetId("")
+		return nil
+	} else if err != nil {
+		return errorDiags(err)
+	}
+	c, err := api.DatabaseCredentialFromSecret(secrets[0])
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	setSecretName(d, secrets[0])
+	setDatabaseCredential(d, c)
+	return nil
+}
+
+// ResourceDatabaseCredentialUpdate is used to update our existing resource
+func ResourceDatabaseCredentialUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	client := m.(*api.Client)
+	s := resourceDatabaseCredential(d)
+	s.ID = d.Id()
+	if d.HasChanges("name", "folder") {
+		// move in place, keeping the ID
+		err := client.RenameContext(ctx, s.ID, s.Name)
+		if err != nil {
+			return errorDiags(err)
+		}
+	}
+	err := client.UpdateContext(ctx, s)
+	if err != nil {
+		return errorDiags(err)
+	}
+	return ResourceDatabaseCredentialRead(ctx, d, m)
+}
+
+// ResourceDatabaseCredentialImporter is called to import an existing Database note.
+func ResourceDatabaseCredentialImporter(ctx context.Context, d *schema.ResourceData, m interface{}) ([]*schema.ResourceData, error) {
+	if _, err := strconv.Atoi(d.Id()); err != nil {
+		return nil, errors.New("Not a valid Lastpass ID")
+	}
+	diags := ResourceDatabaseCredentialRead(ctx, d, m)
+	if diags.HasError() {
+		return nil, errors.New(diags[0].Summary)
+	}
+	if d.Id() == "" {
+		return nil, errors.New("database credential not found")
+	}
+	return []*schema.ResourceData{d}, nil
+}
@@ -0,0 +1,49 @@
+package lastpass
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/nrkno/terraform-provider-lastpass/api"
+)
+
+func TestResourceDatabaseCredential(t *testing.T) {
+	ctx := context.Background()
+	client := &api.Client{Backend: &api.MemoryBackend{}}
+	d := schema.TestResourceDataRaw(t, ResourceDatabaseCredential().Schema, map[string]interface{}{
+		"name":     "app",
+		"folder":   "Databases",
+		"type":     "PostgreSQL",
+		"hostname": "db.example.com",
+		"port":     5432,
+		"database": "app",
+		"username": "app",
+		"password": "hunter2",
+	})
+	diags := ResourceDatabaseCredentialCreate(ctx, d, client)
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+	secrets, err := client.Read(d.Id())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := secrets[0]; s.NoteType != "Database" || s.CustomFields["Port"] != "5432" || s.CustomFields["Hostname"] != "db.example.com" {
+		t.Errorf("unexpected Database note: %+v", s)
+	}
+	d.Set("port", 6432)
+	diags = ResourceDatabaseCredentialUpdate(ctx, d, client)
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+
+	ds := schema.TestResourceDataRaw(t, DataSourceDatabaseCredential().Schema, map[string]interface{}{"fullname": "Databases/app"})
+	diags = DataSourceDatabaseCredentialRead(ctx, ds, client)
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+	if ds.Id() != d.Id() || ds.Get("port") != 6432 || ds.Get("password") != "hunter2" || ds.Get("type") != "PostgreSQL" {
+		t.Errorf("unexpected data source state: %v", ds.State().Attributes)
+	}
+}
@@ -0,0 +1,116 @@
+package lastpass
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/nrkno/terraform-provider-lastpass/api"
+)
+
+// ResourceFolder describes our lastpass folder resource
+func ResourceFolder() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: ResourceFolderCreate,
+		ReadContext:   ResourceFolderRead,
+		UpdateContext: ResourceFolderUpdate,
+		DeleteContext: ResourceFolderDelete,
+		Importer: &schema.ResourceImporter{
+			StateContext: ResourceFolderImporter,
+		},
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(5 * time.Minute),
+			Read:   schema.DefaultTimeout(2 * time.Minute),
+			Update: schema.DefaultTimeout(10 * time.Minute),
+			Delete: schema.DefaultTimeout(2 * time.Minute),
+		},
+
+		Schema: map[string]*schema.Schema{
+			"name": {
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "Full path of the folder, e.g. Infra/Databases.",
+				ValidateFunc: validation.All(
+					validation.StringIsNotEmpty,
+					validation.StringDoesNotMatch(regexp.MustCompile(`^/|/$`), "must not start or end with /"),
+				),
+			},
+			"group": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The parent folder.",
+			},
+		},
+	}
+}
+
+// ResourceFolderCreate is used to create a new folder.
+func ResourceFolderCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	client := m.(*api.Client)
+	folder, err := client.CreateFolderContext(ctx, d.Get("name").(string))
+	if err != nil {
+		return errorDiags(err)
+	}
+	d.SetId(folder.ID)
+	return ResourceFolderRead(ctx, d, m)
+}
+
+// ResourceFolderRead is used to sync the local state with the actual state (upstream/lastpass)
+func ResourceFolderRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	client := m.(*api.Client)
+	secrets, err := client.ReadContext(ctx, d.Id())
+	if errors.Is(err, api.ErrNotFound) {
+		// removed outside of Terraform
+		d.SetId("")
+		return nil
+	} else if err != nil {
+		return errorDiags(err)
+	}
+	if !secrets[0].IsFolder() {
+		return diag.Errorf("%s (%s) is not a folder", secrets[0].Fullname, d.Id())
+	}
+	d.Set("name", secrets[0].Fullname)
+	d.Set("group", secrets[0].Group)
+	return nil
+}
+
+// ResourceFolderUpdate renames the folder, moving everything inside it.
+func ResourceFolderUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	client := m.(*api.Client)
+	err := client.RenameFolderContext(ctx, d.Id(), d.Get("name").(string))
+	if err != nil {
+		return errorDiags(err)
+	}
+	return ResourceFolderRead(ctx, d, m)
+}
+
+// ResourceFolderDelete is called to destroy the folder, it has to be empty.
+func ResourceFolderDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	client := m.(*api.Client)
+	err := client.DeleteFolderContext(ctx, d.Id())
+	if err != nil && !errors.Is(err, api.ErrNotFound) {
+		return errorDiags(err)
+	}
+	return nil
+}
+
+// ResourceFolderImporter is called to import an existing folder.
+func ResourceFolderImporter(ctx context.Context, d *schema.ResourceData, m interface{}) ([]*schema.ResourceData, error) {
+	if _, err := strconv.Atoi(d.Id()); err != nil {
+		err := errors.New("Not a valid Lastpass ID")
+		return nil, err
+	}
+	diags := ResourceFolderRead(ctx, d, m)
+	if diags.HasError() {
+		return nil, errors.New(diags[0].Summary)
+	}
+	if d.Id() == "" {
+		return nil, errors.New("ID not found")
+	}
+	return []*schema.ResourceData{d}, nil
+}
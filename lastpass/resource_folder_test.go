@@ -0,0 +1,52 @@
+package lastpass
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/nrkno/terraform-provider-lastpass/api"
+)
+
+func TestResourceFolder(t *testing.T) {
+	ctx := context.Background()
+	client := &api.Client{Backend: &api.MemoryBackend{}}
+	folder := schema.TestResourceDataRaw(t, ResourceFolder().Schema, map[string]interface{}{"name": "Infra/Databases"})
+	diags := ResourceFolderCreate(ctx, folder, client)
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+	if folder.Get("group") != "Infra" {
+		t.Errorf("expected parent folder Infra, got %q", folder.Get("group"))
+	}
+	secret := schema.TestResourceDataRaw(t, ResourceSecret().Schema, map[string]interface{}{"name": "db", "folder": "Infra/Databases"})
+	diags = ResourceSecretCreate(ctx, secret, client)
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+	if secret.Get("fullname") != "Infra/Databases/db" || secret.Get("name") != "db" {
+		t.Errorf("secret not created in folder: %v", secret.State().Attributes)
+	}
+	diags = ResourceFolderDelete(ctx, folder, client)
+	if len(diags) != 1 || diags[0].Summary != "Folder not empty" {
+		t.Errorf("expected folder not empty error, got %v", diags)
+	}
+	folder.Set("name", "Apps/Databases")
+	diags = ResourceFolderUpdate(ctx, folder, client)
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+	id := secret.Id()
+	diags = ResourceSecretRead(ctx, secret, client)
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+	if secret.Id() != id || secret.Get("fullname") != "Apps/Databases/db" || secret.Get("folder") != "Apps/Databases" || secret.Get("name") != "db" {
+		t.Errorf("secret not moved in place with its folder: %v", secret.State().Attributes)
+	}
+	ResourceSecretDelete(ctx, secret, client)
+	diags = ResourceFolderDelete(ctx, folder, client)
+	if diags.HasError() {
+		t.Errorf("unexpected diagnostics deleting empty folder: %v", diags)
+	}
+}
@@ -2,11 +2,18 @@ package lastpass
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
+	"fmt"
+	"regexp"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 	"github.com/nrkno/terraform-provider-lastpass/api"
 )
 
@@ -18,29 +25,89 @@ func ResourceSecret() *schema.Resource {
 		UpdateContext: ResourceSecretUpdate,
 		DeleteContext: ResourceSecretDelete,
 		Importer: &schema.ResourceImporter{
-			State: ResourceSecretImporter,
+			StateContext: ResourceSecretImporter,
+		},
+		CustomizeDiff: resourceSecretCustomizeDiff,
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(5 * time.Minute),
+			Read:   schema.DefaultTimeout(2 * time.Minute),
+			Update: schema.DefaultTimeout(2 * time.Minute),
+			Delete: schema.DefaultTimeout(2 * time.Minute),
 		},
 
 		Schema: map[string]*schema.Schema{
 			"name": {
 				Type:     schema.TypeString,
 				Required: true,
-				ForceNew: true,
+			},
+			"folder": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Description:  "Folder of the secret, name is relative to it when set.",
+				ValidateFunc: validation.StringDoesNotMatch(regexp.MustCompile(`^/|/$`), "must not start or end with /"),
 			},
 			"fullname": {
 				Type:     schema.TypeString,
 				Computed: true,
 			},
 			"username": {
-				Type:     schema.TypeString,
-				Optional: true,
-				Computed: true,
+				Type:             schema.TypeString,
+				Optional:         true,
+				DiffSuppressFunc: suppressUnmanaged,
 			},
 			"password": {
-				Type:      schema.TypeString,
-				Optional:  true,
-				Sensitive: true,
-				Computed:  true,
+				Type:             schema.TypeString,
+				Optional:         true,
+				Sensitive:        true,
+				Computed:         true, // set by generate_password
+				ConflictsWith:    []string{"generate_password"},
+				DiffSuppressFunc: suppressUnmanaged,
+			},
+			"generate_password": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				MaxItems:    1,
+				Description: "Generate the password instead of setting it.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"length": {
+							Type:         schema.TypeInt,
+							Optional:     true,
+							Default:      32,
+							ValidateFunc: validation.IntBetween(4, 1024),
+						},
+						"lower": {
+							Type:     schema.TypeBool,
+							Optional: true,
+							Default:  true,
+						},
+						"upper": {
+							Type:     schema.TypeBool,
+							Optional: true,
+							Default:  true,
+						},
+						"numeric": {
+							Type:     schema.TypeBool,
+							Optional: true,
+							Default:  true,
+						},
+						"special": {
+							Type:     schema.TypeBool,
+							Optional: true,
+							Default:  true,
+						},
+						"exclude_characters": {
+							Type:     schema.TypeString,
+							Optional: true,
+						},
+						"keepers": {
+							Type:        schema.TypeMap,
+							Optional:    true,
+							Elem:        &schema.Schema{Type: schema.TypeString},
+							Description: "Arbitrary values, changing them generates a new password.",
+						},
+					},
+				},
 			},
 			"last_modified_gmt": {
 				Type:     schema.TypeString,
@@ -50,21 +117,83 @@ func ResourceSecret() *schema.Resource {
 				Type:     schema.TypeString,
 				Computed: true,
 			},
+			"last_modified": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "RFC3339 time of the last change.",
+			},
+			"last_touched": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "RFC3339 time the secret was last used.",
+			},
+			"password_last_changed": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "RFC3339 time the password last changed.",
+			},
+			"password_age_days": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "Days since the password last changed.",
+			},
+			"share": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The shared folder the secret is in.",
+			},
+			"share_readonly": {
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
+			"is_shared": {
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
 			"group": {
 				Type:     schema.TypeString,
 				Computed: true,
 			},
 			"url": {
-				Type:     schema.TypeString,
-				Optional: true,
-				Computed: true,
+				Type:             schema.TypeString,
+				Optional:         true,
+				DiffSuppressFunc: suppressUnmanaged,
 			},
 			"note": {
+				Type:             schema.TypeString,
+				Optional:         true,
+				Sensitive:        true,
+				Description:      "The secret note content.",
+				DiffSuppressFunc: suppressUnmanaged,
+			},
+			"manage_username": manageSchema("username"),
+			"manage_password": manageSchema("password"),
+			"manage_note":     manageSchema("note"),
+			"manage_url":      manageSchema("url"),
+			"password_hash": {
 				Type:        schema.TypeString,
-				Optional:    true,
-				Sensitive:   true,
 				Computed:    true,
-				Description: "The secret note content.",
+				Description: "SHA-256 of the password in Lastpass, changes when it is rotated.",
+			},
+			"note_hash": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "SHA-256 of the note in Lastpass, changes when it is edited.",
+			},
+			"note_type": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				ValidateFunc: validation.StringInSlice(api.NoteTypeNames(), false),
+				Description:  "The secure note template, e.g. Server or Database.",
+			},
+			"custom_fields": {
+				Type:         schema.TypeMap,
+				Optional:     true,
+				Sensitive:    true,
+				Elem:         &schema.Schema{Type: schema.TypeString},
+				RequiredWith: []string{"note_type"},
+				Description:  "Fields of the secure note template.",
 			},
 		},
 	}
@@ -74,16 +203,22 @@ func ResourceSecret() *schema.Resource {
 func ResourceSecretCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
 	client := m.(*api.Client)
 	var diags diag.Diagnostics
-	s := api.Secret{
-		Name:     d.Get("name").(string),
-		URL:      d.Get("url").(string),
-		Username: d.Get("username").(string),
-		Password: d.Get("password").(string),
-		Note:     d.Get("note").(string),
+	s := resourceSecret(d)
+	for _, k := range managedFields {
+		if manageMode(d, k) == manageNever {
+			setSecretValue(&s, k, "")
+		}
 	}
-	s, err := client.Create(s)
+	if policy, ok := resourceSecretPolicy(d); ok {
+		password, err := api.GeneratePassword(policy)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		s.Password = password
+	}
+	s, err := client.CreateContext(ctx, s)
 	if err != nil {
-		return diag.FromErr(err)
+		return errorDiags(err)
 	}
 	d.SetId(s.ID)
 	ResourceSecretRead(ctx, d, m)
@@ -95,44 +230,56 @@ func ResourceSecretCreate(ctx context.Context, d *schema.ResourceData, m interfa
 func ResourceSecretRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
 	client := m.(*api.Client)
 	var diags diag.Diagnostics
-	secrets, err := client.Read(d.Id())
-	if err != nil {
-		return diag.FromErr(err)
-	}
-	if len(secrets) == 0 {
+	secrets, err := client.ReadContext(ctx, d.Id())
+	if errors.Is(err, api.ErrNotFound) {
+		// removed outside of Terraform
 		d.SetId("")
 		return nil
-	} else if len(secrets) > 1 {
+	} else if err != nil {
+		return errorDiags(err)
+	}
+	if len(secrets) > 1 {
 		var err = errors.New("got duplicate IDs")
 		return diag.FromErr(err)
 	}
-	d.Set("name", secrets[0].Name)
-	d.Set("fullname", secrets[0].Fullname)
-	d.Set("username", secrets[0].Username)
-	d.Set("password", secrets[0].Password)
-	d.Set("last_modified_gmt", secrets[0].LastModifiedGmt)
-	d.Set("last_touch", secrets[0].LastTouch)
-	d.Set("group", secrets[0].Group)
-	d.Set("url", secrets[0].URL)
-	d.Set("note", secrets[0].Note)
+	setResourceSecret(d, secrets[0])
 
 	return diags
 }
 
 // ResourceSecretUpdate is used to update our existing resource
 func ResourceSecretUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
-	s := api.Secret{
-		Name:     d.Get("name").(string),
-		URL:      d.Get("url").(string),
-		Username: d.Get("username").(string),
-		Password: d.Get("password").(string),
-		Note:     d.Get("note").(string),
-		ID:       d.Id(),
-	}
+	s := resourceSecret(d)
+	s.ID = d.Id()
 	client := m.(*api.Client)
-	err := client.Update(s)
+	if unmanaged := unmanagedFields(d); len(unmanaged) > 0 {
+		// keep what is in Lastpass for the fields we don't manage
+		secrets, err := client.ReadContext(ctx, s.ID)
+		if err != nil {
+			return errorDiags(err)
+		}
+		upstream := secretValues(secrets[0])
+		for _, k := range unmanaged {
+			setSecretValue(&s, k, upstream[k])
+		}
+	}
+	if policy, ok := resourceSecretPolicy(d); ok && d.HasChange("generate_password") {
+		password, err := api.GeneratePassword(policy)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		s.Password = password
+	}
+	if d.HasChanges("name", "folder") {
+		// move in place, keeping the ID
+		err := client.RenameContext(ctx, s.ID, s.Name)
+		if err != nil {
+			return errorDiags(err)
+		}
+	}
+	err := client.UpdateContext(ctx, s)
 	if err != nil {
-		return diag.FromErr(err)
+		return errorDiags(err)
 	}
 	return ResourceSecretRead(ctx, d, m)
 }
@@ -141,39 +288,251 @@ func ResourceSecretUpdate(ctx context.Context, d *schema.ResourceData, m interfa
 func ResourceSecretDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
 	client := m.(*api.Client)
 	var diags diag.Diagnostics
-	err := client.Delete(d.Id())
-	if err != nil {
-		return diag.FromErr(err)
+	err := client.DeleteContext(ctx, d.Id())
+	if err != nil && !errors.Is(err, api.ErrNotFound) {
+		return errorDiags(err)
 	}
 	return diags
 }
 
 // ResourceSecretImporter is called to import an existing resource.
-func ResourceSecretImporter(d *schema.ResourceData, m interface{}) ([]*schema.ResourceData, error) {
+func ResourceSecretImporter(ctx context.Context, d *schema.ResourceData, m interface{}) ([]*schema.ResourceData, error) {
 	if _, err := strconv.Atoi(d.Id()); err != nil {
 		err := errors.New("Not a valid Lastpass ID")
 		return nil, err
 	}
 	client := m.(*api.Client)
-	secrets, err := client.Read(d.Id())
-	if err != nil {
-		return nil, err
-	}
-	if len(secrets) == 0 {
+	secrets, err := client.ReadContext(ctx, d.Id())
+	if errors.Is(err, api.ErrNotFound) {
 		var err = errors.New("ID not found")
 		return nil, err
-	} else if len(secrets) > 1 {
+	} else if err != nil {
+		return nil, err
+	}
+	if len(secrets) > 1 {
 		var err = errors.New("got duplicate IDs")
 		return nil, err
 	}
-	d.Set("name", secrets[0].Name)
-	d.Set("fullname", secrets[0].Fullname)
-	d.Set("username", secrets[0].Username)
-	d.Set("password", secrets[0].Password)
-	d.Set("last_modified_gmt", secrets[0].LastModifiedGmt)
-	d.Set("last_touch", secrets[0].LastTouch)
-	d.Set("group", secrets[0].Group)
-	d.Set("url", secrets[0].URL)
-	d.Set("note", secrets[0].Note)
+	setResourceSecret(d, secrets[0])
 	return []*schema.ResourceData{d}, nil
 }
+
+// Modes of the manage_* arguments.
+const (
+	manageAlways     = "always"
+	manageCreateOnly = "create_only"
+	manageNever      = "never"
+)
+
+// managedFields are the arguments with a manage_* mode.
+var managedFields = []string{"username", "password", "note", "url"}
+
+func manageSchema(field string) *schema.Schema {
+	return &schema.Schema{
+		Type:         schema.TypeString,
+		Optional:     true,
+		Default:      manageAlways,
+		ValidateFunc: validation.StringInSlice([]string{manageAlways, manageCreateOnly, manageNever}, false),
+		Description:  "When Terraform writes " + field + ": always, create_only or never.",
+	}
+}
+
+// manageMode returns the manage_* mode of a field, imported secrets have no
+// mode yet and are read like always.
+func manageMode(d *schema.ResourceData, field string) string {
+	mode := d.Get("manage_" + field).(string)
+	if mode == "" {
+		return manageAlways
+	}
+	return mode
+}
+
+// unmanagedFields returns the fields Terraform only writes on create, if at all.
+func unmanagedFields(d *schema.ResourceData) []string {
+	var fields []string
+	for _, k := range managedFields {
+		if manageMode(d, k) != manageAlways {
+			fields = append(fields, k)
+		}
+	}
+	return fields
+}
+
+// suppressUnmanaged ignores changes to fields Terraform no longer writes.
+func suppressUnmanaged(k, old, new string, d *schema.ResourceData) bool {
+	return d.Id() != "" && manageMode(d, k) != manageAlways
+}
+
+// resourceSecret builds the secret described by the resource data.
+func resourceSecret(d *schema.ResourceData) api.Secret {
+	s := api.Secret{
+		Name:     secretFullname(d.Get("folder").(string), d.Get("name").(string)),
+		URL:      d.Get("url").(string),
+		Username: d.Get("username").(string),
+		Password: d.Get("password").(string),
+		Note:     d.Get("note").(string),
+		NoteType: d.Get("note_type").(string),
+	}
+	if s.NoteType != "" {
+		// secure notes have no URL of their own
+		s.URL = ""
+		s.CustomFields = make(map[string]string)
+		for k, v := range d.Get("custom_fields").(map[string]interface{}) {
+			s.CustomFields[k] = v.(string)
+		}
+	}
+	return s
+}
+
+// secretFullname returns the full name of a secret in folder.
+func secretFullname(folder, name string) string {
+	if folder == "" {
+		return name
+	}
+	return folder + "/" + name
+}
+
+// setSecretName sets the name, folder and fullname of a secret. The name is
+// relative to the folder, when one is given.
+func setSecretName(d *schema.ResourceData, s api.Secret) {
+	d.Set("name", s.Name)
+	if folder := d.Get("folder").(string); folder != "" {
+		// name is relative to the folder, unless the secret was moved out of it
+		if !strings.HasPrefix(s.Fullname, folder+"/") {
+			folder = s.Group
+		}
+		d.Set("folder", folder)
+		d.Set("name", strings.TrimPrefix(s.Fullname, folder+"/"))
+	}
+	d.Set("fullname", s.Fullname)
+}
+
+// setResourceSecret copies a secret read from Lastpass into the resource data.
+// The username, password and notes of a secure note are fields of its
+// template, they are set on their own attributes rather than custom_fields.
+func setResourceSecret(d *schema.ResourceData, s api.Secret) {
+	setSecretName(d, s)
+	d.Set("last_modified_gmt", s.LastModifiedGmt)
+	d.Set("last_touch", s.LastTouch)
+	d.Set("group", s.Group)
+	d.Set("share", s.Share)
+	d.Set("share_readonly", s.ShareReadonly)
+	d.Set("is_shared", s.Share != "")
+	d.Set("note_type", s.NoteType)
+	values := secretValues(s)
+	for _, k := range managedFields {
+		// unmanaged fields keep the value Terraform knows about
+		if manageMode(d, k) == manageAlways || d.IsNewResource() {
+			d.Set(k, values[k])
+		}
+	}
+	setSecretTimes(d, s)
+	d.Set("password_hash", hashValue(values["password"]))
+	d.Set("note_hash", hashValue(values["note"]))
+	fields := make(map[string]string)
+	for k, v := range s.CustomFields {
+		// templates list every field, skip the unused ones to avoid diffs
+		if !reservedField(k) && v != "" {
+			fields[k] = v
+		}
+	}
+	d.Set("custom_fields", fields)
+}
+
+// secretValues returns the fields manage_* applies to. The username,
+// password and notes of a secure note are fields of its template, and the
+// URL Lastpass gives notes isn't theirs.
+func secretValues(s api.Secret) map[string]string {
+	if s.NoteType == "" {
+		return map[string]string{"username": s.Username, "password": s.Password, "note": s.Note, "url": s.URL}
+	}
+	return map[string]string{
+		"username": s.CustomFields["Username"],
+		"password": s.CustomFields["Password"],
+		"note":     s.CustomFields["Notes"],
+		"url":      "",
+	}
+}
+
+func setSecretValue(s *api.Secret, k, v string) {
+	switch k {
+	case "username":
+		s.Username = v
+	case "password":
+		s.Password = v
+	case "note":
+		s.Note = v
+	case "url":
+		s.URL = v
+	}
+}
+
+func hashValue(v string) string {
+	if v == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(v))
+	return hex.EncodeToString(sum[:])
+}
+
+func stringInSlice(v string, list []string) bool {
+	for _, s := range list {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
+
+// reservedField reports whether a secure note field is managed by another
+// attribute than custom_fields.
+func reservedField(name string) bool {
+	switch name {
+	case "NoteType", "Language", "Notes", "Username", "Password":
+		return true
+	}
+	return false
+}
+
+// resourceSecretCustomizeDiff rejects custom fields set by other arguments,
+// map keys can't be validated by the schema itself. It also marks the
+// password as changing when it will be generated again.
+func resourceSecretCustomizeDiff(ctx context.Context, d *schema.ResourceDiff, m interface{}) error {
+	for k := range d.Get("custom_fields").(map[string]interface{}) {
+		if reservedField(k) {
+			return fmt.Errorf("custom_fields: %q can not be set, use the username, password or note arguments instead", k)
+		}
+		if strings.ContainsAny(k, ":\n") {
+			return fmt.Errorf("custom_fields: %q can not contain colons or new lines", k)
+		}
+		if fields := api.NoteTypeFields(d.Get("note_type").(string)); fields != nil && !stringInSlice(k, fields) {
+			return fmt.Errorf("custom_fields: %q is not a field of %s notes, expected one of %s", k, d.Get("note_type"), strings.Join(fields, ", "))
+		}
+	}
+	if d.Id() != "" && d.HasChange("generate_password") && len(d.Get("generate_password").([]interface{})) > 0 {
+		// a new password is generated on update
+		return d.SetNewComputed("password")
+	}
+	return nil
+}
+
+// resourceSecretPolicy returns the generate_password block, if any.
+func resourceSecretPolicy(d *schema.ResourceData) (api.PasswordPolicy, bool) {
+	blocks := d.Get("generate_password").([]interface{})
+	if len(blocks) == 0 {
+		return api.PasswordPolicy{}, false
+	}
+	if blocks[0] == nil {
+		// an empty block uses the defaults
+		return api.PasswordPolicy{Length: 32, Lower: true, Upper: true, Numeric: true, Special: true}, true
+	}
+	b := blocks[0].(map[string]interface{})
+	return api.PasswordPolicy{
+		Length:  b["length"].(int),
+		Lower:   b["lower"].(bool),
+		Upper:   b["upper"].(bool),
+		Numeric: b["numeric"].(bool),
+		Special: b["special"].(bool),
+		Exclude: b["exclude_characters"].(string),
+	}, true
+}
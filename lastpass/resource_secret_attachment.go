@@ -0,0 +1,167 @@
+package lastpass
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/nrkno/terraform-provider-lastpass/api"
+)
+
+// ResourceSecretAttachment describes our lastpass secret attachment resource
+func ResourceSecretAttachment() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: ResourceSecretAttachmentCreate,
+		ReadContext:   ResourceSecretAttachmentRead,
+		DeleteContext: ResourceSecretAttachmentDelete,
+		Importer: &schema.ResourceImporter{
+			StateContext: ResourceSecretAttachmentImporter,
+		},
+		CustomizeDiff: resourceSecretAttachmentCustomizeDiff,
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(2 * time.Minute),
+			Read:   schema.DefaultTimeout(2 * time.Minute),
+			Delete: schema.DefaultTimeout(2 * time.Minute),
+		},
+
+		Schema: map[string]*schema.Schema{
+			"secret_id": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"name": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validation.StringIsNotEmpty,
+				Description:  "File name of the attachment.",
+			},
+			"content_base64": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				ForceNew:     true,
+				Sensitive:    true,
+				ValidateFunc: validation.StringIsBase64,
+			},
+			"size": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "Size of the attachment in bytes.",
+			},
+		},
+	}
+}
+
+// resourceSecretAttachmentCustomizeDiff fails the plan of a new attachment
+// when the backend can't upload it, existing ones can still be imported.
+func resourceSecretAttachmentCustomizeDiff(ctx context.Context, d *schema.ResourceDiff, m interface{}) error {
+	if d.Id() != "" {
+		return nil
+	}
+	if !m.(*api.Client).CanWriteAttachments() {
+		return errors.New("the backend can't upload attachments, upload the file in the Lastpass web interface and import it")
+	}
+	if _, ok := d.GetOk("content_base64"); !ok {
+		return errors.New("content_base64 is required to upload a new attachment")
+	}
+	return nil
+}
+
+// ResourceSecretAttachmentCreate uploads the attachment, lpass is unable to
+// do so and the api reports ErrUnsupported for it.
+func ResourceSecretAttachmentCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	client := m.(*api.Client)
+	content, err := base64.StdEncoding.DecodeString(d.Get("content_base64").(string))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	secretID := d.Get("secret_id").(string)
+	a, err := client.AddAttachmentContext(ctx, secretID, api.Attachment{Filename: d.Get("name").(string), Content: content})
+	if err != nil {
+		return errorDiags(err)
+	}
+	d.SetId(secretID + ":" + a.ID)
+	return ResourceSecretAttachmentRead(ctx, d, m)
+}
+
+// ResourceSecretAttachmentRead is used to sync the local state with the actual state (upstream/lastpass)
+func ResourceSecretAttachmentRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	client := m.(*api.Client)
+	secretID, attachmentID, err := parseSecretAttachmentID(d.Id())
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	attachments, err := client.AttachmentsContext(ctx, secretID)
+	if errors.Is(err, api.ErrNotFound) {
+		// the secret was removed outside of Terraform
+		d.SetId("")
+		return nil
+	} else if err != nil {
+		return errorDiags(err)
+	}
+	for _, a := range attachments {
+		if a.ID == attachmentID {
+			d.Set("secret_id", secretID)
+			d.Set("name", a.Filename)
+			d.Set("content_base64", base64.StdEncoding.EncodeToString(a.Content))
+			d.Set("size", len(a.Content))
+			return nil
+		}
+	}
+	// removed outside of Terraform
+	d.SetId("")
+	return nil
+}
+
+// ResourceSecretAttachmentDelete removes the attachment. When the backend is
+// unable to, the attachment is left in Lastpass with a warning.
+func ResourceSecretAttachmentDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	client := m.(*api.Client)
+	secretID, attachmentID, err := parseSecretAttachmentID(d.Id())
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	err = client.DeleteAttachmentContext(ctx, secretID, attachmentID)
+	if errors.Is(err, api.ErrUnsupported) {
+		return diag.Diagnostics{{
+			Severity: diag.Warning,
+			Summary:  "Attachment left in Lastpass",
+			Detail:   fmt.Sprintf("%s is no longer managed by Terraform, but the backend can't remove it. Delete it in the Lastpass web interface if it is no longer needed.", d.Get("name")),
+		}}
+	} else if err != nil && !errors.Is(err, api.ErrNotFound) {
+		return errorDiags(err)
+	}
+	return nil
+}
+
+// ResourceSecretAttachmentImporter is called to import an existing attachment,
+// the ID is the secret ID and attachment ID separated by a colon.
+func ResourceSecretAttachmentImporter(ctx context.Context, d *schema.ResourceData, m interface{}) ([]*schema.ResourceData, error) {
+	if _, _, err := parseSecretAttachmentID(d.Id()); err != nil {
+		return nil, err
+	}
+	diags := ResourceSecretAttachmentRead(ctx, d, m)
+	if diags.HasError() {
+		return nil, errors.New(diags[0].Summary)
+	}
+	if d.Id() == "" {
+		return nil, errors.New("attachment not found")
+	}
+	return []*schema.ResourceData{d}, nil
+}
+
+func parseSecretAttachmentID(id string) (secretID, attachmentID string, err error) {
+	parts := strings.SplitN(id, ":", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("invalid ID %q, expected SECRET_ID:ATTACHMENT_ID", id)
+	}
+	return parts[0], parts[1], nil
+}
@@ -1,10 +1,15 @@
 package lastpass
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
 	"github.com/nrkno/terraform-provider-lastpass/api"
 )
@@ -34,6 +39,335 @@ func TestAccResourceSecret_Basic(t *testing.T) {
 	})
 }
 
+func TestResourceSecretCustomFields(t *testing.T) {
+	ctx := context.Background()
+	client := &api.Client{Backend: &api.MemoryBackend{}}
+	raw := map[string]interface{}{
+		"name":          "myserver",
+		"username":      "root",
+		"password":      "hunter2",
+		"note":          "FOO\nBAR\n",
+		"note_type":     "Server",
+		"custom_fields": map[string]interface{}{"Hostname": "example.com"},
+	}
+	d := schema.TestResourceDataRaw(t, ResourceSecret().Schema, raw)
+	diags := ResourceSecretCreate(ctx, d, client)
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+	secrets, err := client.Read(d.Id())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if secrets[0].NoteType != "Server" || secrets[0].CustomFields["Hostname"] != "example.com" {
+		t.Errorf("secure note not stored: %+v", secrets[0])
+	}
+	for k, v := range map[string]string{"username": "root", "password": "hunter2", "note": "FOO\nBAR\n", "note_type": "Server"} {
+		if got := d.Get(k).(string); got != v {
+			t.Errorf("expected %s %q after read, got %q", k, v, got)
+		}
+	}
+	// empty fields of the template are not read back
+	fields := d.Get("custom_fields").(map[string]interface{})
+	if len(fields) != 1 || fields["Hostname"] != "example.com" {
+		t.Errorf("unexpected custom fields after read: %v", fields)
+	}
+	diff, err := ResourceSecret().Diff(ctx, d.State(), terraform.NewResourceConfigRaw(raw), client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff != nil && len(diff.Attributes) > 0 {
+		t.Errorf("expected no diff after read, got %v", diff)
+	}
+}
+
+func TestAccResourceSecret_Rename(t *testing.T) {
+	var before, after api.Secret
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccResourceSecretDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccResourceSecretConfig_rename("terraform-provider-lastpass resource rename test"),
+				Check:  testAccResourceSecretExists("lastpass_secret.foobar", &before),
+			},
+			{
+				Config: testAccResourceSecretConfig_rename("terraform-provider-lastpass/resource renamed test"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccResourceSecretExists("lastpass_secret.foobar", &after),
+					resource.TestCheckResourceAttr(
+						"lastpass_secret.foobar", "fullname", "terraform-provider-lastpass/resource renamed test"),
+					func(*terraform.State) error {
+						if before.ID != after.ID {
+							return fmt.Errorf("secret recreated with ID %s, expected %s", after.ID, before.ID)
+						}
+						return nil
+					},
+				),
+			},
+		},
+	})
+}
+
+func TestAccResourceSecret_GeneratePassword(t *testing.T) {
+	var first, second api.Secret
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccResourceSecretDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccResourceSecretConfig_generate("1"),
+				Check:  testAccResourceSecretExists("lastpass_secret.foobar", &first),
+			},
+			{
+				Config: testAccResourceSecretConfig_generate("2"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccResourceSecretExists("lastpass_secret.foobar", &second),
+					func(*terraform.State) error {
+						if len(first.Password) != 20 || first.Password == second.Password {
+							return fmt.Errorf("expected a new 20 character password, got %d characters", len(second.Password))
+						}
+						return nil
+					},
+				),
+			},
+		},
+	})
+}
+
+func TestResourceSecretGeneratePassword(t *testing.T) {
+	ctx := context.Background()
+	client := &api.Client{Backend: &api.MemoryBackend{}}
+	d := schema.TestResourceDataRaw(t, ResourceSecret().Schema, map[string]interface{}{
+		"name": "generated",
+		"generate_password": []interface{}{map[string]interface{}{
+			"length":  20,
+			"special": false,
+		}},
+	})
+	diags := ResourceSecretCreate(ctx, d, client)
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+	secrets, _ := client.Read(d.Id())
+	password := d.Get("password").(string)
+	if len(password) != 20 || secrets[0].Password != password {
+		t.Errorf("expected a generated password of 20 characters, got %q", password)
+	}
+	if strings.ContainsAny(password, "!@#$%^&*") {
+		t.Errorf("special characters disabled, got %q", password)
+	}
+}
+
+func TestResourceSecretCustomizeDiff(t *testing.T) {
+	ctx := context.Background()
+	client := &api.Client{Backend: &api.MemoryBackend{}}
+	generate := func(length int, keepers map[string]interface{}) map[string]interface{} {
+		return map[string]interface{}{
+			"name": "generated",
+			"generate_password": []interface{}{map[string]interface{}{
+				"length":  length,
+				"keepers": keepers,
+			}},
+		}
+	}
+	raw := generate(20, map[string]interface{}{"rotation": "1"})
+	d := schema.TestResourceDataRaw(t, ResourceSecret().Schema, raw)
+	diags := ResourceSecretCreate(ctx, d, client)
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+	state := d.State()
+	tests := []struct {
+		name       string
+		raw        map[string]interface{}
+		regenerate bool
+	}{
+		{"unchanged", raw, false},
+		{"length changed", generate(24, map[string]interface{}{"rotation": "1"}), true},
+		{"keepers changed", generate(20, map[string]interface{}{"rotation": "2"}), true},
+	}
+	for _, tt := range tests {
+		diff, err := ResourceSecret().Diff(ctx, state, terraform.NewResourceConfigRaw(tt.raw), client)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		var regenerate bool
+		if diff != nil && diff.Attributes["password"] != nil {
+			regenerate = diff.Attributes["password"].NewComputed
+		}
+		if regenerate != tt.regenerate {
+			t.Errorf("%s: expected password regenerated %t, got diff %v", tt.name, tt.regenerate, diff)
+		}
+	}
+	for _, raw := range []map[string]interface{}{
+		{"name": "db", "note_type": "Server", "custom_fields": map[string]interface{}{"Username": "root"}},
+		{"name": "db", "note_type": "SSH Key", "custom_fields": map[string]interface{}{"Port": "22"}},
+	} {
+		if _, err := ResourceSecret().Diff(ctx, nil, terraform.NewResourceConfigRaw(raw), client); err == nil {
+			t.Errorf("expected an error for custom fields %v", raw["custom_fields"])
+		}
+	}
+}
+
+func TestResourceSecretRemoveAttribute(t *testing.T) {
+	ctx := context.Background()
+	client := &api.Client{Backend: &api.MemoryBackend{}}
+	raw := map[string]interface{}{
+		"name":     "db",
+		"username": "gopher",
+		"password": "hunter2",
+		"url":      "https://example.com",
+		"note":     "initial",
+	}
+	d := schema.TestResourceDataRaw(t, ResourceSecret().Schema, raw)
+	diags := ResourceSecretCreate(ctx, d, client)
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+	// removing url and note from the configuration clears them in Lastpass
+	config := map[string]interface{}{"name": "db", "username": "gopher", "password": "hunter2"}
+	diff, err := ResourceSecret().Diff(ctx, d.State(), terraform.NewResourceConfigRaw(config), client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, k := range []string{"url", "note"} {
+		if attr := diff.Attributes[k]; attr == nil || attr.New != "" {
+			t.Errorf("expected %s to be cleared, got diff %v", k, diff)
+		}
+	}
+	state, diags := ResourceSecret().Apply(ctx, d.State(), diff, client)
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+	secrets, err := client.Read(state.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := secrets[0]; s.URL != "" || s.Note != "" || s.Username != "gopher" || s.Password != "hunter2" {
+		t.Errorf("expected url and note to be cleared, got %+v", s)
+	}
+	// fields Terraform doesn't manage are left alone
+	raw["manage_note"] = "create_only"
+	d = schema.TestResourceDataRaw(t, ResourceSecret().Schema, raw)
+	diags = ResourceSecretCreate(ctx, d, client)
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+	config = map[string]interface{}{"name": "db", "username": "gopher", "password": "hunter2", "url": "https://example.com", "manage_note": "create_only"}
+	diff, err = ResourceSecret().Diff(ctx, d.State(), terraform.NewResourceConfigRaw(config), client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff != nil && diff.Attributes["note"] != nil {
+		t.Errorf("expected no diff for an unmanaged note, got %v", diff)
+	}
+}
+
+func TestResourceSecretRename(t *testing.T) {
+	ctx := context.Background()
+	client := &api.Client{Backend: &api.MemoryBackend{}}
+	d := schema.TestResourceDataRaw(t, ResourceSecret().Schema, map[string]interface{}{"name": "Infra/db", "password": "hunter2"})
+	diags := ResourceSecretCreate(ctx, d, client)
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+	id := d.Id()
+	d.Set("name", "Apps/database")
+	diags = ResourceSecretUpdate(ctx, d, client)
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+	if d.Id() != id || d.Get("fullname") != "Apps/database" || d.Get("group") != "Apps" || d.Get("password") != "hunter2" {
+		t.Errorf("secret not renamed in place: %v", d.State().Attributes)
+	}
+}
+
+func TestResourceSecretManagePassword(t *testing.T) {
+	ctx := context.Background()
+	client := &api.Client{Backend: &api.MemoryBackend{}}
+	d := schema.TestResourceDataRaw(t, ResourceSecret().Schema, map[string]interface{}{
+		"name":            "db",
+		"password":        "hunter2",
+		"note":            "initial",
+		"manage_password": "create_only",
+	})
+	diags := ResourceSecretCreate(ctx, d, client)
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+	hash := d.Get("password_hash").(string)
+	if hash == "" {
+		t.Fatal("password_hash not set")
+	}
+	// rotated outside of Terraform
+	secrets, err := client.Read(d.Id())
+	if err != nil {
+		t.Fatal(err)
+	}
+	secrets[0].Password = "rotated"
+	if err := client.Update(secrets[0]); err != nil {
+		t.Fatal(err)
+	}
+	diags = ResourceSecretRead(ctx, d, client)
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+	if d.Get("password") != "hunter2" {
+		t.Errorf("password = %q, want the value from the configuration", d.Get("password"))
+	}
+	if d.Get("password_hash") == hash {
+		t.Error("password_hash did not change after rotation")
+	}
+	d.Set("note", "changed")
+	diags = ResourceSecretUpdate(ctx, d, client)
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+	secrets, err = client.Read(d.Id())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if secrets[0].Password != "rotated" || secrets[0].Note != "changed" {
+		t.Errorf("update reverted the rotation: password %q, note %q", secrets[0].Password, secrets[0].Note)
+	}
+}
+
+func TestResourceSecretPasswordChanged(t *testing.T) {
+	ctx := context.Background()
+	client := &api.Client{Backend: &api.MemoryBackend{}}
+	d := schema.TestResourceDataRaw(t, ResourceSecret().Schema, map[string]interface{}{"name": "db", "password": "hunter2"})
+	diags := ResourceSecretCreate(ctx, d, client)
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+	changed := d.Get("password_last_changed").(string)
+	if _, err := time.Parse(time.RFC3339, changed); err != nil {
+		t.Errorf("password_last_changed: %v", err)
+	}
+	if _, err := time.Parse(time.RFC3339, d.Get("last_modified").(string)); err != nil {
+		t.Errorf("last_modified: %v", err)
+	}
+	if age, ok := d.GetOk("password_age_days"); ok && age != 0 {
+		t.Errorf("password_age_days = %v for a new secret", age)
+	}
+	// a backend that doesn't know when the password changed leaves it null
+	d = schema.TestResourceDataRaw(t, ResourceSecret().Schema, map[string]interface{}{"name": "db"})
+	d.SetId("1")
+	setSecretTimes(d, api.Secret{LastModifiedGmt: "1617281234"})
+	state := d.State().Attributes
+	if state["last_modified"] != "2021-04-01T12:47:14Z" {
+		t.Errorf("last_modified = %q", state["last_modified"])
+	}
+	for _, k := range []string{"password_last_changed", "password_age_days"} {
+		if v, ok := state[k]; ok {
+			t.Errorf("%s = %q, want null", k, v)
+		}
+	}
+}
+
 func testAccResourceSecretDestroy(s *terraform.State) error {
 	c := testAccProvider.Meta().(*api.Client)
 
@@ -44,11 +378,11 @@ func testAccResourceSecretDestroy(s *terraform.State) error {
 		orderID := rs.Primary.ID
 
 		err := c.Delete(orderID)
-		if err != nil {
+		if err != nil && !errors.Is(err, api.ErrNotFound) {
 			return err
 		}
-		secrets, _ := c.Read(rs.Primary.ID)
-		if len(secrets) > 0 {
+		_, err = c.Read(rs.Primary.ID)
+		if !errors.Is(err, api.ErrNotFound) {
 			return fmt.Errorf("Secret still exists")
 		}
 	}
@@ -87,3 +421,25 @@ FOO
 BAR
 EOF
 }`
+
+func testAccResourceSecretConfig_rename(name string) string {
+	return fmt.Sprintf(`
+resource "lastpass_secret" "foobar" {
+    name = %q
+    username = "gopher"
+    password = "hunter2"
+}`, name)
+}
+
+func testAccResourceSecretConfig_generate(keeper string) string {
+	return fmt.Sprintf(`
+resource "lastpass_secret" "foobar" {
+    name = "terraform-provider-lastpass resource generate test"
+    generate_password {
+        length = 20
+        keepers = {
+            rotation = %q
+        }
+    }
+}`, keeper)
+}
@@ -0,0 +1,149 @@
+package lastpass
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/nrkno/terraform-provider-lastpass/api"
+)
+
+// ResourceServerCredential describes our lastpass server credential resource
+func ResourceServerCredential() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: ResourceServerCredentialCreate,
+		ReadContext:   ResourceServerCredentialRead,
+		UpdateContext: ResourceServerCredentialUpdate,
+		DeleteContext: ResourceSecretDelete,
+		Importer: &schema.ResourceImporter{
+			StateContext: ResourceServerCredentialImporter,
+		},
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(5 * time.Minute),
+			Read:   schema.DefaultTimeout(2 * time.Minute),
+			Update: schema.DefaultTimeout(2 * time.Minute),
+			Delete: schema.DefaultTimeout(2 * time.Minute),
+		},
+
+		Schema: map[string]*schema.Schema{
+			"name": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"folder": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Folder of the credential, name is relative to it.",
+			},
+			"fullname": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"hostname": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"username": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"password": {
+				Type:      schema.TypeString,
+				Optional:  true,
+				Sensitive: true,
+			},
+			"note": {
+				Type:      schema.TypeString,
+				Optional:  true,
+				Sensitive: true,
+			},
+		},
+	}
+}
+
+func resourceServerCredential(d *schema.ResourceData) api.Secret {
+	c := api.ServerCredential{
+		Hostname: d.Get("hostname").(string),
+		Username: d.Get("username").(string),
+		Password: d.Get("password").(string),
+		Note:     d.Get("note").(string),
+	}
+	return c.Secret(secretFullname(d.Get("folder").(string), d.Get("name").(string)))
+}
+
+// setServerCredential sets the fields of a credential, shared with the data
+// source.
+func setServerCredential(d *schema.ResourceData, c api.ServerCredential) {
+	d.Set("hostname", c.Hostname)
+	d.Set("username", c.Username)
+	d.Set("password", c.Password)
+	d.Set("note", c.Note)
+}
+
+// ResourceServerCredentialCreate stores the credential as a Server note.
+func ResourceServerCredentialCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	client := m.(*api.Client)
+	s, err := client.CreateContext(ctx, resourceServerCredential(d))
+	if err != nil {
+		return errorDiags(err)
+	}
+	d.SetId(s.ID)
+	return ResourceServerCredentialRead(ctx, d, m)
+}
+
+// ResourceServerCredentialRead is used to sync the local state with the actual state (upstream/lastpass)
+func ResourceServerCredentialRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	client := m.(*api.Client)
+	secrets, err := client.ReadContext(ctx, d.Id())
+	if errors.Is(err, api.ErrNotFound) {
+		// removed outside of Terraform
+		d.SetId("")
+		return nil
+	} else if err != nil {
+		return errorDiags(err)
+	}
+	c, err := api.ServerCredentialFromSecret(secrets[0])
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	setSecretName(d, secrets[0])
+	setServerCredential(d, c)
+	return nil
+}
+
+// ResourceServerCredentialUpdate is used to update our existing resource
+func ResourceServerCredentialUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	client := m.(*api.Client)
+	s := resourceServerCredential(d)
+	s.ID = d.Id()
+	if d.HasChanges("name", "folder") {
+		// move in place, keeping the ID
+		err := client.RenameContext(ctx, s.ID, s.Name)
+		if err != nil {
+			return errorDiags(err)
+		}
+	}
+	err := client.UpdateContext(ctx, s)
+	if err != nil {
+		return errorDiags(err)
+	}
+	return ResourceServerCredentialRead(ctx, d, m)
+}
+
+// ResourceServerCredentialImporter is called to import an existing Server note.
+func ResourceServerCredentialImporter(ctx context.Context, d *schema.ResourceData, m interface{}) ([]*schema.ResourceData, error) {
+	if _, err := strconv.Atoi(d.Id()); err != nil {
+		return nil, errors.New("Not a valid Lastpass ID")
+	}
+	diags := ResourceServerCredentialRead(ctx, d, m)
+	if diags.HasError() {
+		return nil, errors.New(diags[0].Summary)
+	}
+	if d.Id() == "" {
+		return nil, errors.New("server credential not found")
+	}
+	return []*schema.ResourceData{d}, nil
+}
@@ -0,0 +1,115 @@
+package lastpass
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/nrkno/terraform-provider-lastpass/api"
+)
+
+func TestResourceServerCredential(t *testing.T) {
+	ctx := context.Background()
+	client := &api.Client{Backend: &api.MemoryBackend{}}
+	d := schema.TestResourceDataRaw(t, ResourceServerCredential().Schema, map[string]interface{}{
+		"name":     "web",
+		"hostname": "web.example.com",
+		"username": "root",
+		"password": "hunter2",
+		"note":     "rotated by hand",
+	})
+	diags := ResourceServerCredentialCreate(ctx, d, client)
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+	secrets, err := client.Read(d.Id())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := secrets[0]; s.NoteType != "Server" || s.CustomFields["Hostname"] != "web.example.com" || s.CustomFields["Notes"] != "rotated by hand" {
+		t.Errorf("unexpected Server note: %+v", s)
+	}
+	ds := schema.TestResourceDataRaw(t, DataSourceServerCredential().Schema, map[string]interface{}{"id": d.Id()})
+	diags = DataSourceServerCredentialRead(ctx, ds, client)
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+	if ds.Get("fullname") != "web" || ds.Get("hostname") != "web.example.com" || ds.Get("username") != "root" || ds.Get("password") != "hunter2" {
+		t.Errorf("unexpected data source state: %v", ds.State().Attributes)
+	}
+	// a server note is not a database
+	db := schema.TestResourceDataRaw(t, DataSourceDatabaseCredential().Schema, map[string]interface{}{"id": d.Id()})
+	diags = DataSourceDatabaseCredentialRead(ctx, db, client)
+	if !diags.HasError() {
+		t.Error("expected an error reading a Server note as a database credential")
+	}
+}
+
+func TestResourceServerCredentialUpdate(t *testing.T) {
+	ctx := context.Background()
+	client := &api.Client{Backend: &api.MemoryBackend{}}
+	d := schema.TestResourceDataRaw(t, ResourceServerCredential().Schema, map[string]interface{}{
+		"name":     "web",
+		"hostname": "web.example.com",
+		"password": "hunter2",
+	})
+	diags := ResourceServerCredentialCreate(ctx, d, client)
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+	id := d.Id()
+	d.Set("folder", "Servers")
+	d.Set("hostname", "web2.example.com")
+	diags = ResourceServerCredentialUpdate(ctx, d, client)
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+	if d.Id() != id || d.Get("fullname") != "Servers/web" || d.Get("hostname") != "web2.example.com" || d.Get("password") != "hunter2" {
+		t.Errorf("credential not updated in place: %v", d.State().Attributes)
+	}
+	ds := schema.TestResourceDataRaw(t, DataSourceServerCredential().Schema, map[string]interface{}{"fullname": "Servers/web"})
+	diags = DataSourceServerCredentialRead(ctx, ds, client)
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+	if ds.Id() != id || ds.Get("hostname") != "web2.example.com" {
+		t.Errorf("unexpected data source state: %v", ds.State().Attributes)
+	}
+	diags = ResourceSecretDelete(ctx, d, client)
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+	d.SetId(id)
+	diags = ResourceServerCredentialRead(ctx, d, client)
+	if diags.HasError() || d.Id() != "" {
+		t.Errorf("expected a deleted credential to be removed from state, got %q, %v", d.Id(), diags)
+	}
+}
+
+func TestResourceServerCredentialImporter(t *testing.T) {
+	ctx := context.Background()
+	client := &api.Client{Backend: &api.MemoryBackend{}}
+	db, err := client.Create(api.DatabaseCredential{Hostname: "db"}.Secret("db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv, err := client.Create(api.ServerCredential{Hostname: "web"}.Secret("web"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := schema.TestResourceDataRaw(t, ResourceServerCredential().Schema, map[string]interface{}{})
+	d.SetId(srv.ID)
+	if _, err := ResourceServerCredentialImporter(ctx, d, client); err != nil {
+		t.Fatal(err)
+	}
+	if d.Get("name") != "web" || d.Get("hostname") != "web" {
+		t.Errorf("unexpected imported state: %v", d.State().Attributes)
+	}
+	for _, id := range []string{"not-a-number", db.ID, "42"} {
+		d := schema.TestResourceDataRaw(t, ResourceServerCredential().Schema, map[string]interface{}{})
+		d.SetId(id)
+		if _, err := ResourceServerCredentialImporter(ctx, d, client); err == nil {
+			t.Errorf("expected an error importing %s", id)
+		}
+	}
+}
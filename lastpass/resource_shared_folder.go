@@ -0,0 +1,78 @@
+package lastpass
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/nrkno/terraform-provider-lastpass/api"
+)
+
+// ResourceSharedFolder describes our lastpass shared folder resource
+func ResourceSharedFolder() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: ResourceSharedFolderCreate,
+		ReadContext:   ResourceSharedFolderRead,
+		DeleteContext: ResourceSharedFolderDelete,
+		Importer: &schema.ResourceImporter{
+			StateContext: schema.ImportStatePassthroughContext,
+		},
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(2 * time.Minute),
+			Read:   schema.DefaultTimeout(2 * time.Minute),
+			Delete: schema.DefaultTimeout(2 * time.Minute),
+		},
+
+		Schema: map[string]*schema.Schema{
+			"name": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				Description:  "Name of the shared folder, e.g. Shared-Infra.",
+				ValidateFunc: validation.StringMatch(regexp.MustCompile(`^Shared-[^/]+$`), "must start with Shared- and not contain /"),
+			},
+		},
+	}
+}
+
+// ResourceSharedFolderCreate is used to create a new shared folder.
+func ResourceSharedFolderCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	client := m.(*api.Client)
+	name := d.Get("name").(string)
+	err := client.CreateShareContext(ctx, name)
+	if err != nil {
+		return errorDiags(err)
+	}
+	d.SetId(name)
+	return ResourceSharedFolderRead(ctx, d, m)
+}
+
+// ResourceSharedFolderRead checks the shared folder still exists.
+func ResourceSharedFolderRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	client := m.(*api.Client)
+	_, err := client.ShareUsersContext(ctx, d.Id())
+	if errors.Is(err, api.ErrNotFound) {
+		// removed outside of Terraform
+		d.SetId("")
+		return nil
+	} else if err != nil {
+		return errorDiags(err)
+	}
+	d.Set("name", d.Id())
+	return nil
+}
+
+// ResourceSharedFolderDelete is called to destroy the shared folder, and
+// everything inside it.
+func ResourceSharedFolderDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	client := m.(*api.Client)
+	err := client.DeleteShareContext(ctx, d.Id())
+	if err != nil && !errors.Is(err, api.ErrNotFound) {
+		return errorDiags(err)
+	}
+	return nil
+}
@@ -0,0 +1,166 @@
+package lastpass
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/nrkno/terraform-provider-lastpass/api"
+)
+
+// ResourceSharedFolderMember describes our lastpass shared folder member resource
+func ResourceSharedFolderMember() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: ResourceSharedFolderMemberCreate,
+		ReadContext:   ResourceSharedFolderMemberRead,
+		UpdateContext: ResourceSharedFolderMemberUpdate,
+		DeleteContext: ResourceSharedFolderMemberDelete,
+		Importer: &schema.ResourceImporter{
+			StateContext: ResourceSharedFolderMemberImporter,
+		},
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(2 * time.Minute),
+			Read:   schema.DefaultTimeout(2 * time.Minute),
+			Update: schema.DefaultTimeout(2 * time.Minute),
+			Delete: schema.DefaultTimeout(2 * time.Minute),
+		},
+
+		Schema: map[string]*schema.Schema{
+			"shared_folder": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"username": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "E-mail address of the Lastpass user.",
+				DiffSuppressFunc: func(k, old, new string, d *schema.ResourceData) bool {
+					return strings.EqualFold(old, new)
+				},
+			},
+			"read_only": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+			"admin": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+			"hide_passwords": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+			"accepted": {
+				Type:        schema.TypeBool,
+				Computed:    true,
+				Description: "Whether the user accepted the invitation.",
+			},
+		},
+	}
+}
+
+func resourceSharedFolderMember(d *schema.ResourceData) api.ShareUser {
+	return api.ShareUser{
+		Username:      d.Get("username").(string),
+		ReadOnly:      d.Get("read_only").(bool),
+		Admin:         d.Get("admin").(bool),
+		HidePasswords: d.Get("hide_passwords").(bool),
+	}
+}
+
+// ResourceSharedFolderMemberCreate invites the user to the shared folder.
+func ResourceSharedFolderMemberCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	client := m.(*api.Client)
+	share := d.Get("shared_folder").(string)
+	u := resourceSharedFolderMember(d)
+	err := client.AddShareUserContext(ctx, share, u)
+	if err != nil {
+		return errorDiags(err)
+	}
+	d.SetId(share + ":" + u.Username)
+	return ResourceSharedFolderMemberRead(ctx, d, m)
+}
+
+// ResourceSharedFolderMemberRead is used to sync the local state with the actual state (upstream/lastpass)
+func ResourceSharedFolderMemberRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	client := m.(*api.Client)
+	share, username, err := parseSharedFolderMemberID(d.Id())
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	users, err := client.ShareUsersContext(ctx, share)
+	if errors.Is(err, api.ErrNotFound) {
+		// the shared folder was removed outside of Terraform
+		d.SetId("")
+		return nil
+	} else if err != nil {
+		return errorDiags(err)
+	}
+	for _, u := range users {
+		if strings.EqualFold(u.Username, username) {
+			d.Set("shared_folder", share)
+			d.Set("username", u.Username)
+			d.Set("read_only", u.ReadOnly)
+			d.Set("admin", u.Admin)
+			d.Set("hide_passwords", u.HidePasswords)
+			d.Set("accepted", u.Accepted)
+			return nil
+		}
+	}
+	// removed outside of Terraform
+	d.SetId("")
+	return nil
+}
+
+// ResourceSharedFolderMemberUpdate changes the permissions of the user.
+func ResourceSharedFolderMemberUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	client := m.(*api.Client)
+	err := client.UpdateShareUserContext(ctx, d.Get("shared_folder").(string), resourceSharedFolderMember(d))
+	if err != nil {
+		return errorDiags(err)
+	}
+	return ResourceSharedFolderMemberRead(ctx, d, m)
+}
+
+// ResourceSharedFolderMemberDelete removes the user from the shared folder.
+func ResourceSharedFolderMemberDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	client := m.(*api.Client)
+	err := client.RemoveShareUserContext(ctx, d.Get("shared_folder").(string), d.Get("username").(string))
+	if err != nil && !errors.Is(err, api.ErrNotFound) {
+		return errorDiags(err)
+	}
+	return nil
+}
+
+// ResourceSharedFolderMemberImporter is called to import an existing member,
+// the ID is the shared folder and username separated by a colon.
+func ResourceSharedFolderMemberImporter(ctx context.Context, d *schema.ResourceData, m interface{}) ([]*schema.ResourceData, error) {
+	if _, _, err := parseSharedFolderMemberID(d.Id()); err != nil {
+		return nil, err
+	}
+	diags := ResourceSharedFolderMemberRead(ctx, d, m)
+	if diags.HasError() {
+		return nil, errors.New(diags[0].Summary)
+	}
+	if d.Id() == "" {
+		return nil, errors.New("member not found")
+	}
+	return []*schema.ResourceData{d}, nil
+}
+
+func parseSharedFolderMemberID(id string) (share, username string, err error) {
+	parts := strings.SplitN(id, ":", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("invalid ID %q, expected SHARED_FOLDER:USERNAME", id)
+	}
+	return parts[0], parts[1], nil
+}
@@ -0,0 +1,78 @@
+package lastpass
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/nrkno/terraform-provider-lastpass/api"
+)
+
+func TestResourceSharedFolderMember(t *testing.T) {
+	ctx := context.Background()
+	client := &api.Client{Backend: &api.MemoryBackend{}}
+	share := schema.TestResourceDataRaw(t, ResourceSharedFolder().Schema, map[string]interface{}{"name": "Shared-Infra"})
+	diags := ResourceSharedFolderCreate(ctx, share, client)
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+	member := schema.TestResourceDataRaw(t, ResourceSharedFolderMember().Schema, map[string]interface{}{
+		"shared_folder": "Shared-Infra",
+		"username":      "ops@example.com",
+		"read_only":     true,
+	})
+	diags = ResourceSharedFolderMemberCreate(ctx, member, client)
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+	if member.Id() != "Shared-Infra:ops@example.com" || !member.Get("read_only").(bool) || member.Get("admin").(bool) {
+		t.Errorf("unexpected member state: %v", member.State().Attributes)
+	}
+	member.Set("read_only", false)
+	member.Set("admin", true)
+	diags = ResourceSharedFolderMemberUpdate(ctx, member, client)
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+	users, _ := client.ShareUsers("Shared-Infra")
+	if len(users) != 1 || users[0].ReadOnly || !users[0].Admin {
+		t.Errorf("member not updated: %+v", users)
+	}
+	diags = ResourceSharedFolderMemberDelete(ctx, member, client)
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+	diags = ResourceSharedFolderMemberRead(ctx, member, client)
+	if diags.HasError() || member.Id() != "" {
+		t.Errorf("removed member should be removed from state: %v", diags)
+	}
+	diags = ResourceSharedFolderDelete(ctx, share, client)
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+	diags = ResourceSharedFolderRead(ctx, share, client)
+	if diags.HasError() || share.Id() != "" {
+		t.Errorf("removed shared folder should be removed from state: %v", diags)
+	}
+}
+
+func TestResourceSecretShared(t *testing.T) {
+	ctx := context.Background()
+	client := &api.Client{Username: "gopher@example.com", Backend: &api.MemoryBackend{}}
+	client.CreateShare("Shared-Infra")
+	client.CreateShare("Shared-RO")
+	client.AddShareUser("Shared-RO", api.ShareUser{Username: "gopher@example.com", ReadOnly: true})
+	d := schema.TestResourceDataRaw(t, ResourceSecret().Schema, map[string]interface{}{"name": "db", "folder": "Shared-Infra"})
+	diags := ResourceSecretCreate(ctx, d, client)
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+	if d.Get("share") != "Shared-Infra" || !d.Get("is_shared").(bool) || d.Get("share_readonly").(bool) {
+		t.Errorf("unexpected share attributes: %v", d.State().Attributes)
+	}
+	d = schema.TestResourceDataRaw(t, ResourceSecret().Schema, map[string]interface{}{"name": "db", "folder": "Shared-RO"})
+	diags = ResourceSecretCreate(ctx, d, client)
+	if len(diags) != 1 || diags[0].Summary != "Shared folder is read-only" {
+		t.Errorf("expected read-only error, got %v", diags)
+	}
+}
@@ -0,0 +1,233 @@
+package lastpass
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/nrkno/terraform-provider-lastpass/api"
+)
+
+// sshKeyNoteType is the secure note template SSH keys are stored in.
+const sshKeyNoteType = "SSH Key"
+
+// ResourceSSHKey describes our lastpass SSH key resource
+func ResourceSSHKey() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: ResourceSSHKeyCreate,
+		ReadContext:   ResourceSSHKeyRead,
+		UpdateContext: ResourceSSHKeyUpdate,
+		DeleteContext: ResourceSecretDelete,
+		Importer: &schema.ResourceImporter{
+			StateContext: ResourceSSHKeyImporter,
+		},
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(5 * time.Minute),
+			Read:   schema.DefaultTimeout(2 * time.Minute),
+			Update: schema.DefaultTimeout(2 * time.Minute),
+			Delete: schema.DefaultTimeout(2 * time.Minute),
+		},
+
+		Schema: map[string]*schema.Schema{
+			"name": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"folder": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Folder of the SSH key, name is relative to it.",
+			},
+			"fullname": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"private_key": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				Computed:      true,
+				ForceNew:      true,
+				Sensitive:     true,
+				ConflictsWith: []string{"algorithm", "bits"},
+				Description:   "PEM encoded private key, generated when not set.",
+				DiffSuppressFunc: func(k, old, new string, d *schema.ResourceData) bool {
+					// Lastpass may drop the trailing new line
+					return strings.TrimSpace(old) == strings.TrimSpace(new)
+				},
+			},
+			"passphrase": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Sensitive:    true,
+				RequiredWith: []string{"private_key"},
+				Description:  "Passphrase of an encrypted private_key.",
+			},
+			"algorithm": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				ForceNew:     true,
+				ValidateFunc: validation.StringInSlice([]string{api.SSHKeyED25519, api.SSHKeyRSA, api.SSHKeyECDSA}, false),
+				Description:  "Algorithm of the generated key, ED25519 by default.",
+			},
+			"bits": {
+				Type:        schema.TypeInt,
+				Optional:    true,
+				Computed:    true,
+				ForceNew:    true,
+				Description: "Size of the generated RSA key or ECDSA curve.",
+			},
+			"hostname": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"note": {
+				Type:      schema.TypeString,
+				Optional:  true,
+				Sensitive: true,
+			},
+			"comment": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Comment of the authorized_key line.",
+			},
+			"public_key": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"fingerprint": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "SHA256 fingerprint, the way ssh-keygen -l shows it.",
+			},
+			"authorized_key": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Line for an authorized_keys file.",
+			},
+		},
+	}
+}
+
+// resourceSSHKey builds the secure note of the key.
+func resourceSSHKey(d *schema.ResourceData, key api.SSHKey) api.Secret {
+	return api.Secret{
+		Name:     secretFullname(d.Get("folder").(string), d.Get("name").(string)),
+		NoteType: sshKeyNoteType,
+		Note:     d.Get("note").(string),
+		CustomFields: map[string]string{
+			"Private Key":  key.PrivateKey,
+			"Public Key":   key.PublicKey,
+			"Passphrase":   d.Get("passphrase").(string),
+			"Hostname":     d.Get("hostname").(string),
+			"Bit Strength": strconv.Itoa(key.Bits),
+		},
+	}
+}
+
+// resourceSSHKeyPair returns the configured private key, or generates one.
+func resourceSSHKeyPair(d *schema.ResourceData) (api.SSHKey, error) {
+	if privateKey := d.Get("private_key").(string); privateKey != "" {
+		return api.ParseSSHKey(privateKey, d.Get("passphrase").(string))
+	}
+	algorithm := d.Get("algorithm").(string)
+	if algorithm == "" {
+		algorithm = api.SSHKeyED25519
+	}
+	return api.GenerateSSHKey(algorithm, d.Get("bits").(int))
+}
+
+// ResourceSSHKeyCreate stores the key, generating it when no private_key is given.
+func ResourceSSHKeyCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	client := m.(*api.Client)
+	key, err := resourceSSHKeyPair(d)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	s, err := client.CreateContext(ctx, resourceSSHKey(d, key))
+	if err != nil {
+		return errorDiags(err)
+	}
+	d.SetId(s.ID)
+	return ResourceSSHKeyRead(ctx, d, m)
+}
+
+// ResourceSSHKeyRead is used to sync the local state with the actual state (upstream/lastpass)
+func ResourceSSHKeyRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	client := m.(*api.Client)
+	secrets, err := client.ReadContext(ctx, d.Id())
+	if errors.Is(err, api.ErrNotFound) {
+		// removed outside of Terraform
+		d.SetId("")
+		return nil
+	} else if err != nil {
+		return errorDiags(err)
+	}
+	s := secrets[0]
+	if s.NoteType != sshKeyNoteType {
+		return diag.Errorf("%s (%s) is not an SSH Key note, but %q", s.Fullname, s.ID, s.NoteType)
+	}
+	setSecretName(d, s)
+	d.Set("private_key", s.CustomFields["Private Key"])
+	d.Set("passphrase", s.CustomFields["Passphrase"])
+	d.Set("hostname", s.CustomFields["Hostname"])
+	d.Set("note", s.CustomFields["Notes"])
+	key, err := api.ParseSSHKey(s.CustomFields["Private Key"], s.CustomFields["Passphrase"])
+	if err != nil {
+		return diag.Errorf("invalid private key in %s: %v", s.Fullname, err)
+	}
+	d.Set("algorithm", key.Algorithm)
+	d.Set("bits", key.Bits)
+	d.Set("public_key", key.PublicKey)
+	d.Set("fingerprint", key.Fingerprint)
+	authorizedKey := key.PublicKey
+	if comment := d.Get("comment").(string); comment != "" {
+		authorizedKey += " " + comment
+	}
+	d.Set("authorized_key", authorizedKey+"\n")
+	return nil
+}
+
+// ResourceSSHKeyUpdate changes the name, hostname or note, the key itself is
+// replaced by recreating the resource.
+func ResourceSSHKeyUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	client := m.(*api.Client)
+	key, err := api.ParseSSHKey(d.Get("private_key").(string), d.Get("passphrase").(string))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	s := resourceSSHKey(d, key)
+	s.ID = d.Id()
+	if d.HasChanges("name", "folder") {
+		// move in place, keeping the ID
+		err := client.RenameContext(ctx, s.ID, s.Name)
+		if err != nil {
+			return errorDiags(err)
+		}
+	}
+	err = client.UpdateContext(ctx, s)
+	if err != nil {
+		return errorDiags(err)
+	}
+	return ResourceSSHKeyRead(ctx, d, m)
+}
+
+// ResourceSSHKeyImporter is called to import an existing SSH Key note.
+func ResourceSSHKeyImporter(ctx context.Context, d *schema.ResourceData, m interface{}) ([]*schema.ResourceData, error) {
+	if _, err := strconv.Atoi(d.Id()); err != nil {
+		return nil, errors.New("Not a valid Lastpass ID")
+	}
+	diags := ResourceSSHKeyRead(ctx, d, m)
+	if diags.HasError() {
+		return nil, errors.New(diags[0].Summary)
+	}
+	if d.Id() == "" {
+		return nil, errors.New("SSH key not found")
+	}
+	return []*schema.ResourceData{d}, nil
+}
@@ -0,0 +1,83 @@
+package lastpass
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/nrkno/terraform-provider-lastpass/api"
+)
+
+func TestResourceSSHKeyGenerate(t *testing.T) {
+	ctx := context.Background()
+	client := &api.Client{Backend: &api.MemoryBackend{}}
+	d := schema.TestResourceDataRaw(t, ResourceSSHKey().Schema, map[string]interface{}{
+		"name":     "deploy",
+		"folder":   "Infra",
+		"hostname": "git.example.com",
+		"comment":  "deploy@example.com",
+	})
+	diags := ResourceSSHKeyCreate(ctx, d, client)
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+	publicKey := d.Get("public_key").(string)
+	if d.Get("algorithm") != api.SSHKeyED25519 || !strings.HasPrefix(publicKey, "ssh-ed25519 ") {
+		t.Errorf("expected an ED25519 key: %v", d.State().Attributes)
+	}
+	if d.Get("authorized_key") != publicKey+" deploy@example.com\n" || !strings.HasPrefix(d.Get("fingerprint").(string), "SHA256:") {
+		t.Errorf("unexpected public attributes: %v", d.State().Attributes)
+	}
+	secrets, err := client.Read(d.Id())
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := secrets[0]
+	if s.Fullname != "Infra/deploy" || s.NoteType != "SSH Key" || s.CustomFields["Public Key"] != publicKey || s.CustomFields["Hostname"] != "git.example.com" {
+		t.Errorf("unexpected SSH Key note: %+v", s)
+	}
+
+	d.Set("name", "deploy-key")
+	diags = ResourceSSHKeyUpdate(ctx, d, client)
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+	if d.Get("fullname") != "Infra/deploy-key" || d.Get("public_key") != publicKey {
+		t.Errorf("key not renamed in place: %v", d.State().Attributes)
+	}
+}
+
+func TestResourceSSHKeyPrivateKey(t *testing.T) {
+	ctx := context.Background()
+	client := &api.Client{Backend: &api.MemoryBackend{}}
+	key, err := api.GenerateSSHKey(api.SSHKeyECDSA, 384)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := schema.TestResourceDataRaw(t, ResourceSSHKey().Schema, map[string]interface{}{
+		"name":        "deploy",
+		"private_key": key.PrivateKey,
+	})
+	diags := ResourceSSHKeyCreate(ctx, d, client)
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+	if d.Get("public_key") != key.PublicKey || d.Get("fingerprint") != key.Fingerprint || d.Get("bits") != 384 {
+		t.Errorf("unexpected key attributes: %v", d.State().Attributes)
+	}
+}
+
+func TestResourceSSHKeyImportOtherNote(t *testing.T) {
+	ctx := context.Background()
+	client := &api.Client{Backend: &api.MemoryBackend{}}
+	s, err := client.Create(api.Secret{Name: "db", NoteType: "Database"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := schema.TestResourceDataRaw(t, ResourceSSHKey().Schema, map[string]interface{}{})
+	d.SetId(s.ID)
+	if _, err := ResourceSSHKeyImporter(ctx, d, client); err == nil {
+		t.Error("expected an error importing a Database note")
+	}
+}